@@ -0,0 +1,287 @@
+package crud
+
+import (
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// testCSRFToken is the csrf token sent by the test requests.
+const testCSRFToken = "test-token"
+
+// newTestAdmin returns an admin on the database.
+func newTestAdmin(t *testing.T, db *sql.DB, opts ...Option) *Admin {
+	t.Helper()
+
+	a, err := New(append([]Option{WithDB(db)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+
+	return a
+}
+
+// headerUser returns the user named by the X-User header of the test requests.
+func headerUser(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
+
+// testRequest returns a request of the user, sending back the csrf token as the admin forms do.
+func testRequest(method, target, user string, form url.Values) *http.Request {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	r := httptest.NewRequest(method, target, body)
+	if form != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if user != "" {
+		r.Header.Set("X-User", user)
+	}
+	r.AddCookie(&http.Cookie{Name: csrfCookie, Value: testCSRFToken})
+	r.Header.Set(csrfHeader, testCSRFToken)
+
+	return r
+}
+
+// serve returns the response of the handler to the request.
+func serve(h http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// readerChecker allows every action to the editor, and only reading to the reader.
+func readerChecker(r *http.Request, userID, entityName, action string) bool {
+	return userID == "editor" || userID == "reader" && action == "read"
+}
+
+func TestPermissionDenied(t *testing.T) {
+	db := newTestDB(t,
+		"create table notes (id integer primary key, title text not null)",
+		"insert into notes values (1, 'first')",
+	)
+	h := newTestAdmin(t, db, WithEntity(Entity{TableName: "notes"}),
+		WithUserIdentifier(headerUser), WithPermissionChecker(readerChecker)).GetMux()
+
+	tests := []struct {
+		name   string
+		r      *http.Request
+		denied bool
+	}{
+		{"read", testRequest(http.MethodGet, "/admin/entity/notes", "reader", nil), false},
+		{"detail", testRequest(http.MethodGet, "/admin/entity/notes/1", "reader", nil), false},
+		{"new form", testRequest(http.MethodGet, "/admin/entity/notes/new", "reader", nil), true},
+		{"create", testRequest(http.MethodPost, "/admin/entity/notes/new", "reader", url.Values{"title": {"second"}}), true},
+		{"edit form", testRequest(http.MethodGet, "/admin/entity/notes/1/edit", "reader", nil), true},
+		{"update", testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "reader", url.Values{"title": {"changed"}}), true},
+		{"delete", testRequest(http.MethodPost, "/admin/entity/notes/1/delete", "reader", url.Values{}), true},
+		{"editor update", testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "editor", url.Values{"title": {"edited"}}), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.r)
+			if denied := strings.Contains(w.Body.String(), "Permission denied"); denied != tt.denied {
+				t.Errorf("denied = %v, want %v", denied, tt.denied)
+			}
+			if w.Code >= http.StatusInternalServerError {
+				t.Errorf("status = %d", w.Code)
+			}
+		})
+	}
+
+	var titles []string
+	rows, err := db.Query("select title from notes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var title string
+		if err := rows.Scan(&title); err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, title)
+	}
+	if len(titles) != 1 || titles[0] != "edited" {
+		t.Errorf("notes = %v, want only the edit of the editor", titles)
+	}
+}
+
+func TestPermissionLogin(t *testing.T) {
+	db := newTestDB(t, "create table notes (id integer primary key, title text not null)")
+	h := newTestAdmin(t, db, WithEntity(Entity{TableName: "notes"}),
+		WithUserIdentifier(headerUser), WithPermissionChecker(readerChecker)).GetMux()
+
+	w := serve(h, testRequest(http.MethodGet, "/admin/entity/notes?page=2", "", nil))
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/admin/login?next=%2Fadmin%2Fentity%2Fnotes%3Fpage%3D2" {
+		t.Errorf("anonymous request: status = %d, location = %q, want a redirect to the login", w.Code, w.Header().Get("Location"))
+	}
+}
+
+func TestPermissionInlines(t *testing.T) {
+	db := newTestDB(t,
+		"create table notes (id integer primary key, title text not null)",
+		"create table comments (id integer primary key, note_id integer not null references notes, body text not null)",
+		"insert into notes values (1, 'first')",
+		"insert into comments values (1, 1, 'hello')",
+	)
+	// the editor of the notes may not write comments.
+	checker := func(r *http.Request, userID, entityName, action string) bool {
+		return entityName == "notes" || action == "read"
+	}
+	h := newTestAdmin(t, db,
+		WithEntity(Entity{TableName: "notes", Inlines: []Inline{{Entity: "comments", Columns: []string{"body"}}}}),
+		WithEntity(Entity{TableName: "comments"}),
+		WithUserIdentifier(headerUser), WithPermissionChecker(checker)).GetMux()
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{"new comment", url.Values{"title": {"first"}, "inline-comments-0-_pk": {"1"}, "inline-comments-0-body": {"hello"},
+			"inline-comments-1-body": {"sneaky"}}, http.StatusForbidden},
+		{"changed comment", url.Values{"title": {"first"}, "inline-comments-0-_pk": {"1"}, "inline-comments-0-body": {"changed"}}, http.StatusForbidden},
+		{"deleted comment", url.Values{"title": {"first"}, "inline-comments-0-_pk": {"1"}, "inline-comments-0-_delete": {"true"}}, http.StatusForbidden},
+		{"unchanged comments", url.Values{"title": {"renamed"}, "inline-comments-0-_pk": {"1"}, "inline-comments-0-body": {"hello"}}, http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(h, testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "editor", tt.form)); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	var comments []string
+	rows, err := db.Query("select body from comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var body string
+		if err := rows.Scan(&body); err != nil {
+			t.Fatal(err)
+		}
+		comments = append(comments, body)
+	}
+	if len(comments) != 1 || comments[0] != "hello" {
+		t.Errorf("comments = %v, want the comment left as is", comments)
+	}
+}
+
+func TestPermissionLinks(t *testing.T) {
+	db := newTestDB(t,
+		"create table notes (id integer primary key, title text not null)",
+		"create table tags (id integer primary key, name text not null)",
+		"create table note_tags (note_id integer not null, tag_id integer not null, primary key (note_id, tag_id))",
+		"insert into notes values (1, 'first')",
+		"insert into tags values (1, 'go'), (2, 'sql')",
+		"insert into note_tags values (1, 1)",
+	)
+	// the editor of the notes may not link tags.
+	checker := func(r *http.Request, userID, entityName, action string) bool {
+		return entityName == "notes" || action == "read"
+	}
+	h := newTestAdmin(t, db,
+		WithEntity(Entity{TableName: "notes", EditColumns: []string{"title"}, ManyToMany: []ManyToMany{
+			{Entity: "tags", JoinTable: "note_tags", LocalKey: "note_id", RemoteKey: "tag_id", DisplayColumn: "name"},
+		}}),
+		WithEntity(Entity{TableName: "tags"}),
+		WithUserIdentifier(headerUser), WithPermissionChecker(checker)).GetMux()
+
+	tests := []struct {
+		name   string
+		tags   []string
+		status int
+	}{
+		{"added link", []string{"1", "2"}, http.StatusForbidden},
+		{"removed link", []string{""}, http.StatusForbidden},
+		{"unchanged links", []string{"1"}, http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{"title": {"first"}, "m2m-tags": tt.tags}
+			if w := serve(h, testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "editor", form)); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	var count int
+	if err := db.QueryRow("select count(*) from note_tags").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("links = %d, want the link left as is", count)
+	}
+}
+
+func TestPermissionRelatedRows(t *testing.T) {
+	db := newTestDB(t,
+		"create table authors (id integer primary key, name text not null)",
+		"create table tags (id integer primary key, name text not null)",
+		"create table notes (id integer primary key, title text not null, author_id integer references authors)",
+		"create table note_tags (note_id integer not null, tag_id integer not null, primary key (note_id, tag_id))",
+		"create table comments (id integer primary key, note_id integer not null references notes, body text not null)",
+		"insert into authors values (1, 'secret-author')",
+		"insert into tags values (1, 'secret-tag')",
+		"insert into notes values (1, 'first', 1)",
+		"insert into note_tags values (1, 1)",
+		"insert into comments values (1, 1, 'secret-comment')",
+	)
+	// the user may only use the notes.
+	checker := func(r *http.Request, userID, entityName, action string) bool {
+		return entityName == "notes"
+	}
+	h := newTestAdmin(t, db,
+		WithEntity(Entity{
+			TableName:   "notes",
+			EditColumns: []string{"title", "author_id"},
+			Relations:   []Relation{{Column: "author_id", Entity: "authors", DisplayColumn: "name"}},
+			ManyToMany:  []ManyToMany{{Entity: "tags", JoinTable: "note_tags", LocalKey: "note_id", RemoteKey: "tag_id", DisplayColumn: "name"}},
+			Inlines:     []Inline{{Entity: "comments", Columns: []string{"body"}}},
+		}),
+		WithEntity(Entity{TableName: "authors"}),
+		WithEntity(Entity{TableName: "tags"}),
+		WithEntity(Entity{TableName: "comments"}),
+		WithUserIdentifier(headerUser), WithPermissionChecker(checker)).GetMux()
+
+	for _, target := range []string{"/admin/entity/notes", "/admin/entity/notes/1", "/admin/entity/notes/1/edit"} {
+		w := serve(h, testRequest(http.MethodGet, target, "reader", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "first") {
+			t.Fatalf("%s: status = %d, want the note", target, w.Code)
+		}
+		for _, hidden := range []string{"secret-author", "secret-tag", "secret-comment"} {
+			if strings.Contains(w.Body.String(), hidden) {
+				t.Errorf("%s shows %s, which the user may not read", target, hidden)
+			}
+		}
+	}
+
+	// the hidden child rows and links are not posted, and stay unchanged.
+	w := serve(h, testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "reader", url.Values{"title": {"renamed"}, "author_id": {"1"}}))
+	if w.Code != http.StatusFound {
+		t.Fatalf("save: status = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	var comments, links int
+	if err := db.QueryRow("select (select count(*) from comments), (select count(*) from note_tags)").Scan(&comments, &links); err != nil {
+		t.Fatal(err)
+	}
+	if comments != 1 || links != 1 {
+		t.Errorf("comments = %d, links = %d, want them kept", comments, links)
+	}
+}
@@ -1,6 +1,62 @@
 // Call the dataTables jQuery plugin
 $(document).ready(function() {
-  $('#dataTable').DataTable();
+  // paging, sorting and filtering are done on the server, the plugin only handles the current page.
+  $('#dataTable').DataTable({ paging: false, ordering: false, searching: false, info: false });
   $('.datepicker').datepicker();
+
+  // a value typed in a nullable field unchecks its set NULL box.
+  $('[data-null]').on('input change', function() {
+    $('#' + $(this).data('null')).prop('checked', false);
+  });
+
+  initLookups(document);
+
+  // the add button of an inline copies its empty row with the next row index.
+  $('[data-inline]').each(function() {
+    var inline = $(this);
+    inline.find('[data-inline-add]').on('click', function() {
+      var next = inline.data('next');
+      inline.data('next', next + 1);
+      var row = $($.trim(inline.find('template').html().replace(/__new__/g, next)));
+      inline.find('tbody').append(row);
+      initLookups(row);
+    });
+    inline.on('click', '[data-inline-remove]', function() {
+      $(this).closest('tr').remove();
+    });
+  });
 });
 
+// lookup selects get a search box, the options are the rows returned by the lookup endpoint.
+function initLookups(root) {
+  $(root).find('select[data-lookup]').each(function() {
+    var select = $(this);
+    var search = $('<input type="search" class="form-control form-control-sm mb-1" placeholder="Search...">');
+    var timer = null;
+    var loaded = false;
+
+    function load(query) {
+      $.getJSON(select.data('lookup'), { q: query }, function(data) {
+        select.find('option').not('[value=""]').not(':selected').remove();
+        $.each(data.results, function(_, result) {
+          var exists = select.find('option').filter(function() { return this.value === result.id; }).length > 0;
+          if (!exists) {
+            select.append($('<option>').val(result.id).text(result.text));
+          }
+        });
+        loaded = true;
+      });
+    }
+
+    search.insertBefore(select);
+    search.on('input', function() {
+      clearTimeout(timer);
+      timer = setTimeout(function() { load(search.val()); }, 250);
+    });
+    select.add(search).on('focus', function() {
+      if (!loaded) {
+        load(search.val());
+      }
+    });
+  });
+}
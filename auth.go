@@ -0,0 +1,609 @@
+package crud
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// sessionCookie is the name of the cookie of the session of the built-in authentication.
+	sessionCookie = "crud_session"
+	// minPasswordLength is the minimum length of the passwords of the built-in authentication.
+	minPasswordLength = 8
+)
+
+var (
+	errInvalidLogin = errors.New("invalid email or password")
+	errEmailTaken   = errors.New("an account already uses this email")
+	// errRegisterClosed is returned when an account is registered after the first one while registration is
+	// not allowed.
+	errRegisterClosed = errors.New("registration is closed")
+)
+
+// dummyPasswordHash is compared with the password of a login with an unknown email, so it takes as long as a
+// login with a wrong password.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("crud"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Auth represents the built-in authentication. admin users are stored in a table created by crud, their passwords
+// are hashed with bcrypt and the signed in user is kept in a session.
+type Auth struct {
+	// Secret represents the key of the session cookies. it must be at least 32 bytes long.
+	Secret []byte
+	// TableName represents the table of the admin users. default is crud_users. the table is never an entity.
+	TableName string
+	// Mailer represents the sender of the password reset links. it is required, LogMailer logs them instead.
+	Mailer Mailer
+	// AllowRegister lets anyone create an account on the register page. the first account can always be registered.
+	// it needs WithRBAC or a permission checker, so the new accounts do not get every permission.
+	AllowRegister bool
+	// Sessions represents the store of the sessions. default is a CookieSessionStore with the secret, which can
+	// not revoke a session on logout; use a DBSessionStore for that.
+	Sessions SessionStore
+	// IdleTimeout represents how long a session lasts without being used. default is 2 hours.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout represents how long a session lasts after the login. default is 24 hours.
+	AbsoluteTimeout time.Duration
+	// ResetTokenTTL represents how long a password reset link is valid. default is one hour.
+	ResetTokenTTL time.Duration
+	// PublicURL represents the scheme and host of the password reset links, like https://example.com. it is
+	// required, as a link built from the host of the request could be sent to another site.
+	PublicURL string
+}
+
+// authUser represents a row of the admin users table.
+type authUser struct {
+	id           string
+	name         string
+	email        string
+	passwordHash string
+	// resetExpiresAt represents the expiry of the password reset token as unix seconds, zero when there is none.
+	resetExpiresAt int64
+	// sessionsRevokedAt represents when the sessions of the user were revoked as unix milliseconds, the sessions
+	// created before are not valid.
+	sessionsRevokedAt int64
+}
+
+// prepareAuth creates the admin users table of the built-in authentication and prepares the session store.
+// the user is read from the session when no user identifier is set.
+func (a *Admin) prepareAuth(ctx context.Context) error {
+	if a.auth == nil {
+		return nil
+	}
+
+	stmt, err := authTableStmt(a.db.Dialect, a.auth.TableName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.db.querier().ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("create table %q: %w", a.auth.TableName, err)
+	}
+
+	if store, ok := a.auth.Sessions.(sessionPreparer); ok {
+		if err := store.prepare(ctx, a.db, a.auth); err != nil {
+			return err
+		}
+	}
+
+	if a.UserIdentifier == nil {
+		a.UserIdentifier = a.sessionUserID
+	}
+
+	// registered accounts get the access the permissions give them, without permissions they could do anything.
+	if a.auth.AllowRegister && a.PermissionChecker == nil && a.rbac == nil {
+		return errors.New("auth AllowRegister needs WithRBAC or a permission checker")
+	}
+
+	return nil
+}
+
+// authTable reports whether a table is one of the tables of the built-in authentication.
+func (a *Admin) authTable(name string) bool {
+	if a.auth == nil {
+		return false
+	}
+
+	if store, ok := a.auth.Sessions.(*DBSessionStore); ok && name == store.TableName {
+		return true
+	}
+
+	return name == a.auth.TableName
+}
+
+// serialPrimaryKey returns the definition of an id column filled by the database, for the tables created by crud.
+// the ids of deleted rows are never reused, as other tables and the sessions may still name them.
+func serialPrimaryKey(dialect Dialect) (string, error) {
+	switch dialect.Name() {
+	case "postgres":
+		return "bigserial primary key", nil
+	case "mysql":
+		return "bigint auto_increment primary key", nil
+	case "sqlite":
+		return "integer primary key autoincrement", nil
+	}
+
+	return "", fmt.Errorf("the %q dialect is not supported", dialect.Name())
+}
+
+// authTableStmt returns the statement creating the admin users table.
+func authTableStmt(dialect Dialect, name string) (string, error) {
+	id, err := serialPrimaryKey(dialect)
+	if err != nil {
+		return "", fmt.Errorf("built-in auth: %w", err)
+	}
+
+	return fmt.Sprintf(`create table if not exists %s (
+	id %s,
+	name varchar(255) not null,
+	email varchar(255) not null unique,
+	password_hash varchar(255) not null,
+	reset_token_hash varchar(64),
+	reset_expires_at bigint,
+	sessions_revoked_at bigint,
+	created_at timestamp not null default current_timestamp
+)`, dialect.QuoteIdent(name), id), nil
+}
+
+// findUser returns the admin user whose column has the value, or sql.ErrNoRows.
+func (a *Admin) findUser(ctx context.Context, db *DB, column string, value any) (*authUser, error) {
+	q := db.newQuery()
+	stmt := fmt.Sprintf("select %s from %s where %s = %s", q.idents([]string{"id", "name", "email", "password_hash", "reset_expires_at", "sessions_revoked_at"}),
+		q.ident(a.auth.TableName), q.ident(column), q.arg(value))
+
+	var (
+		user    authUser
+		id      any
+		expires sql.NullInt64
+		revoked sql.NullInt64
+	)
+	if err := db.querier().QueryRowContext(ctx, stmt, q.args...).Scan(&id, &user.name, &user.email, &user.passwordHash, &expires, &revoked); err != nil {
+		return nil, err
+	}
+
+	user.id = valueText(id)
+	user.resetExpiresAt = expires.Int64
+	user.sessionsRevokedAt = revoked.Int64
+	return &user, nil
+}
+
+// updateUser sets columns of an admin user.
+func (a *Admin) updateUser(ctx context.Context, id string, values map[string]any) error {
+	q := a.db.newQuery()
+	sets := make([]string, 0, len(values))
+	for column, value := range values {
+		sets = append(sets, q.ident(column)+" = "+q.arg(value))
+	}
+
+	stmt := fmt.Sprintf("update %s set %s where %s = %s", q.ident(a.auth.TableName), strings.Join(sets, ","), q.ident("id"), q.arg(id))
+	_, err := a.db.querier().ExecContext(ctx, stmt, q.args...)
+	return err
+}
+
+// CreateUser adds an admin user to the built-in authentication, like the first user of a new admin.
+func (a *Admin) CreateUser(ctx context.Context, name, email, password string) error {
+	if a.auth == nil {
+		return errors.New("built-in auth is not enabled")
+	}
+
+	_, err := a.createUser(ctx, name, email, password, false)
+	return err
+}
+
+// createUser adds an admin user and returns its id. when first is set, the user is only added if there is no
+// user yet, otherwise errRegisterClosed is returned. the first user gets the admin role of the rbac. the check
+// and the insert run in one serializable transaction, so two accounts can not both be the first one.
+func (a *Admin) createUser(ctx context.Context, name, email, password string, first bool) (string, error) {
+	name, email = strings.TrimSpace(name), normalizeEmail(email)
+	if name == "" {
+		return "", errors.New("name is required")
+	}
+	if !strings.Contains(email, "@") {
+		return "", errors.New("email is not valid")
+	}
+	if err := checkNewPassword(password, password); err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = a.db.withTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable}, func(tx *DB) error {
+		q := tx.newQuery()
+		var count int
+		if err := tx.querier().QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s", q.ident(a.auth.TableName))).Scan(&count); err != nil {
+			return err
+		}
+		if first && count > 0 {
+			return errRegisterClosed
+		}
+
+		if _, err := a.findUser(ctx, tx, "email", email); err == nil {
+			return errEmailTaken
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		q = tx.newQuery()
+		stmt := fmt.Sprintf("insert into %s (%s) values (%s,%s,%s)", q.ident(a.auth.TableName), q.idents([]string{"name", "email", "password_hash"}),
+			q.arg(name), q.arg(email), q.arg(string(hash)))
+		if _, err := tx.querier().ExecContext(ctx, stmt, q.args...); err != nil {
+			return err
+		}
+
+		user, err := a.findUser(ctx, tx, "email", email)
+		if err != nil {
+			return err
+		}
+		id = user.id
+
+		if count > 0 {
+			return nil
+		}
+		return a.assignFirstAdmin(ctx, tx, user.id)
+	})
+
+	return id, err
+}
+
+// authenticate returns the admin user of an email and password, or errInvalidLogin.
+func (a *Admin) authenticate(ctx context.Context, email, password string) (*authUser, error) {
+	user, err := a.findUser(ctx, a.db, "email", normalizeEmail(email))
+	if err == sql.ErrNoRows {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return nil, errInvalidLogin
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.passwordHash), []byte(password)) != nil {
+		return nil, errInvalidLogin
+	}
+
+	return user, nil
+}
+
+// canRegister reports whether the register page is open: registration is allowed or there is no user yet.
+func (a *Admin) canRegister(ctx context.Context) (bool, error) {
+	if a.auth.AllowRegister {
+		return true, nil
+	}
+
+	q := a.db.newQuery()
+	var count int
+	if err := a.db.querier().QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s", q.ident(a.auth.TableName))).Scan(&count); err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+// normalizeEmail returns the email as stored in the admin users table.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkNewPassword returns the error of a new password and its confirmation.
+func checkNewPassword(password, confirm string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	if password != confirm {
+		return errors.New("passwords do not match")
+	}
+
+	return nil
+}
+
+// sentence returns an error as a sentence shown on a page.
+func sentence(err error) string {
+	text := err.Error()
+	return strings.ToUpper(text[:1]) + text[1:] + "."
+}
+
+// hashToken returns the hash of a password reset token, the token itself is never stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newToken returns a random password reset token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// resetUser returns the admin user of a valid password reset token, or sql.ErrNoRows.
+func (a *Admin) resetUser(ctx context.Context, token string) (*authUser, error) {
+	if token == "" {
+		return nil, sql.ErrNoRows
+	}
+
+	user, err := a.findUser(ctx, a.db, "reset_token_hash", hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().Unix() >= user.resetExpiresAt {
+		return nil, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+// resetURL returns the password reset link of a token.
+func (a *Admin) resetURL(token string) string {
+	base := strings.TrimSuffix(a.auth.PublicURL, "/")
+
+	return base + path.Join(a.BaseURL, "/reset-password") + "?" + url.Values{"token": {token}}.Encode()
+}
+
+// localURL returns next when it is a path of this site, so a login never redirects elsewhere, or the dashboard.
+// browsers drop control characters and read backslashes as slashes, so paths with them are never followed.
+func (a *Admin) localURL(next string) string {
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.User != nil {
+		return path.Join(a.BaseURL, "/")
+	}
+
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.ContainsRune(next, '\\') || strings.ContainsFunc(next, unicode.IsControl) {
+		return path.Join(a.BaseURL, "/")
+	}
+
+	return next
+}
+
+// renderAuthPage renders a page of the built-in authentication.
+func (a *Admin) renderAuthPage(w http.ResponseWriter, r *http.Request, name string, status int, data AuthData) {
+	allow, err := a.canRegister(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data.AllowRegister = allow
+	data.BaseContextData = a.getBaseContextData(r)
+
+	w.WriteHeader(status)
+	if err := a.executeTemplate(w, name, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (a *Admin) login(w http.ResponseWriter, r *http.Request) {
+	a.renderAuthPage(w, r, "login", http.StatusOK, AuthData{Next: r.URL.Query().Get("next")})
+}
+
+func (a *Admin) postLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := AuthData{Email: r.PostForm.Get("email"), Next: r.PostForm.Get("next")}
+
+	user, err := a.authenticate(r.Context(), data.Email, r.PostForm.Get("password"))
+	if errors.Is(err, errInvalidLogin) {
+		data.Error = sentence(err)
+		a.renderAuthPage(w, r, "login", http.StatusUnauthorized, data)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := a.startSession(w, r, user.id, r.PostForm.Get("remember") == "true"); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, a.localURL(data.Next), http.StatusFound)
+}
+
+func (a *Admin) logout(w http.ResponseWriter, r *http.Request) {
+	if err := a.endSession(w, r); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, path.Join(a.BaseURL, "/login"), http.StatusFound)
+}
+
+func (a *Admin) register(w http.ResponseWriter, r *http.Request) {
+	allow, err := a.canRegister(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !allow {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+
+	a.renderAuthPage(w, r, "register", http.StatusOK, AuthData{})
+}
+
+func (a *Admin) postRegister(w http.ResponseWriter, r *http.Request) {
+	allow, err := a.canRegister(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !allow {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := AuthData{Name: r.PostForm.Get("name"), Email: r.PostForm.Get("email")}
+	password := r.PostForm.Get("password")
+
+	var id string
+	err = checkNewPassword(password, r.PostForm.Get("confirm"))
+	if err == nil {
+		id, err = a.createUser(r.Context(), data.Name, data.Email, password, !a.auth.AllowRegister)
+	}
+	if err == errRegisterClosed {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+	if err != nil {
+		data.Error = sentence(err)
+		a.renderAuthPage(w, r, "register", http.StatusUnprocessableEntity, data)
+		return
+	}
+
+	if err := a.startSession(w, r, id, false); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.Redirect(w, r, path.Join(a.BaseURL, "/"), http.StatusFound)
+}
+
+func (a *Admin) forgetPassword(w http.ResponseWriter, r *http.Request) {
+	a.renderAuthPage(w, r, "forget_password", http.StatusOK, AuthData{})
+}
+
+// postForgetPassword mails a password reset link to the user of the email. the answer is the same whether
+// the email belongs to a user or not.
+func (a *Admin) postForgetPassword(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := AuthData{Email: r.PostForm.Get("email")}
+
+	user, err := a.findUser(r.Context(), a.db, "email", normalizeEmail(data.Email))
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err == nil {
+		token, err := newToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = a.updateUser(r.Context(), user.id, map[string]any{
+			"reset_token_hash": hashToken(token),
+			"reset_expires_at": time.Now().Add(a.auth.ResetTokenTTL).Unix(),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = a.auth.Mailer.Send(r.Context(), Mail{
+			To:      user.email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf("Hi %s,\n\nOpen this link to choose a new password, it is valid for %s:\n\n%s\n\n"+
+				"If you did not ask for it, you can ignore this mail.\n", user.name, a.auth.ResetTokenTTL, a.resetURL(token)),
+		})
+		if err != nil {
+			http.Error(w, fmt.Sprintf("send mail: %s", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	data.Message = "If an account uses this email, a link to reset its password was sent to it."
+	a.renderAuthPage(w, r, "forget_password", http.StatusOK, data)
+}
+
+func (a *Admin) resetPassword(w http.ResponseWriter, r *http.Request) {
+	data := AuthData{Token: r.URL.Query().Get("token")}
+
+	_, err := a.resetUser(r.Context(), data.Token)
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		a.renderAuthPage(w, r, "reset_password", http.StatusNotFound, AuthData{Error: "The reset link is invalid or expired."})
+		return
+	}
+
+	a.renderAuthPage(w, r, "reset_password", http.StatusOK, data)
+}
+
+func (a *Admin) postResetPassword(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := AuthData{Token: r.PostForm.Get("token")}
+
+	user, err := a.resetUser(r.Context(), data.Token)
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		a.renderAuthPage(w, r, "reset_password", http.StatusNotFound, AuthData{Error: "The reset link is invalid or expired."})
+		return
+	}
+
+	password := r.PostForm.Get("password")
+	if err := checkNewPassword(password, r.PostForm.Get("confirm")); err != nil {
+		data.Error = sentence(err)
+		a.renderAuthPage(w, r, "reset_password", http.StatusUnprocessableEntity, data)
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// the token is cleared so the link works once.
+	err = a.updateUser(r.Context(), user.id, map[string]any{"password_hash": string(hash), "reset_token_hash": nil, "reset_expires_at": nil})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// whoever knew the old password is signed out.
+	if err := a.RevokeSessions(r.Context(), user.id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	a.renderAuthPage(w, r, "login", http.StatusOK, AuthData{Email: user.email, Message: "Your password was changed, you can login now."})
+}
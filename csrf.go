@@ -0,0 +1,80 @@
+package crud
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"path"
+)
+
+const (
+	// csrfCookie is the name of the cookie holding the csrf token.
+	csrfCookie = "crud_csrf"
+	// csrfField is the name of the form field sending back the csrf token.
+	csrfField = "csrf_token"
+	// csrfHeader is the header sending back the csrf token, for the requests made by scripts.
+	csrfHeader = "X-CSRF-Token"
+)
+
+// csrfContextKey is the request context key of the csrf token.
+type csrfContextKey struct{}
+
+// checkCSRF is a middleware protecting the admin from cross-site requests. a random token is kept in a cookie,
+// and the requests changing data must send it back in the csrf_token form field or the X-CSRF-Token header,
+// which other sites can not do as they can not read the cookie. the token is added to the request context for
+// the forms.
+func (a *Admin) checkCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if cookie, err := r.Cookie(csrfCookie); err == nil {
+			token = cookie.Value
+		}
+
+		if !safeMethod(r.Method) {
+			sent := r.Header.Get(csrfHeader)
+			if sent == "" {
+				sent = r.PostFormValue(csrfField)
+			}
+
+			if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+				http.Error(w, "invalid csrf token, reload the page and try again", http.StatusForbidden)
+				return
+			}
+		}
+
+		if token == "" {
+			var err error
+			if token, err = newToken(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			http.SetCookie(w, &http.Cookie{
+				Name:     csrfCookie,
+				Value:    token,
+				Path:     path.Join(a.BaseURL, "/"),
+				HttpOnly: true,
+				Secure:   !a.insecureCookies,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, token)))
+	})
+}
+
+// safeMethod reports whether requests of the method do not change data.
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// csrfToken returns the csrf token of the request, to be posted by the forms.
+func csrfToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfContextKey{}).(string)
+	return token
+}
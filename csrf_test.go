@@ -0,0 +1,109 @@
+package crud
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestCSRF(t *testing.T) {
+	db := newTestDB(t, "create table notes (id integer primary key, title text not null)")
+	h := newTestAdmin(t, db, WithEntity(Entity{TableName: "notes"})).GetMux()
+	form := url.Values{"title": {"note"}}
+
+	tests := []struct {
+		name   string
+		r      func() *http.Request
+		status int
+	}{
+		{"no token", func() *http.Request {
+			r := testRequest(http.MethodPost, "/admin/entity/notes/new", "", form)
+			r.Header.Del("Cookie")
+			r.Header.Del(csrfHeader)
+			return r
+		}, http.StatusForbidden},
+		{"cookie without a sent token", func() *http.Request {
+			r := testRequest(http.MethodPost, "/admin/entity/notes/new", "", form)
+			r.Header.Del(csrfHeader)
+			return r
+		}, http.StatusForbidden},
+		{"sent token without a cookie", func() *http.Request {
+			r := testRequest(http.MethodPost, "/admin/entity/notes/new", "", form)
+			r.Header.Del("Cookie")
+			return r
+		}, http.StatusForbidden},
+		{"wrong token", func() *http.Request {
+			r := testRequest(http.MethodPost, "/admin/entity/notes/new", "", form)
+			r.Header.Set(csrfHeader, "other-token")
+			return r
+		}, http.StatusForbidden},
+		{"header token", func() *http.Request {
+			return testRequest(http.MethodPost, "/admin/entity/notes/new", "", form)
+		}, http.StatusFound},
+		{"form token", func() *http.Request {
+			r := testRequest(http.MethodPost, "/admin/entity/notes/new", "", url.Values{"title": {"note"}, csrfField: {testCSRFToken}})
+			r.Header.Del(csrfHeader)
+			return r
+		}, http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(h, tt.r()); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+
+	var count int
+	if err := db.QueryRow("select count(*) from notes").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("notes = %d, want the 2 notes posted with the token", count)
+	}
+}
+
+func TestCSRFTokenIssued(t *testing.T) {
+	db := newTestDB(t, "create table notes (id integer primary key, title text not null)")
+	h := newTestAdmin(t, db, WithEntity(Entity{TableName: "notes"})).GetMux()
+
+	r := testRequest(http.MethodGet, "/admin/entity/notes/new", "", nil)
+	r.Header.Del("Cookie")
+	w := serve(h, r)
+
+	var token string
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == csrfCookie {
+			token = cookie.Value
+			if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+				t.Errorf("cookie = %+v, want http only, secure and same site lax", cookie)
+			}
+		}
+	}
+	if token == "" {
+		t.Fatal("no csrf cookie issued")
+	}
+	if !strings.Contains(w.Body.String(), `value="`+token+`"`) {
+		t.Error("the form does not send back the issued token")
+	}
+
+	w = serve(h, testRequest(http.MethodGet, "/admin/entity/notes/new", "", nil))
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("a request with a token cookie gets a new cookie")
+	}
+}
+
+func TestCSRFInsecureCookies(t *testing.T) {
+	db := newTestDB(t, "create table notes (id integer primary key, title text not null)")
+	h := newTestAdmin(t, db, WithEntity(Entity{TableName: "notes"}), WithSecureCookies(false)).GetMux()
+
+	r := testRequest(http.MethodGet, "/admin/entity/notes/new", "", nil)
+	r.Header.Del("Cookie")
+	for _, cookie := range serve(h, r).Result().Cookies() {
+		if cookie.Name == csrfCookie && cookie.Secure {
+			t.Errorf("cookie = %+v, want it sent over plain http", cookie)
+		}
+	}
+}
@@ -4,26 +4,168 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"html/template"
+	"math"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
 // DB represents a database.
 type DB struct {
 	URI    string
 	Engine string
+	// Dialect represents the SQL dialect of the database. default is the dialect of the engine.
+	Dialect Dialect
+
+	// MaxOpenConns is the maximum number of open connections. zero means unlimited.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections. zero means the database/sql default.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum amount of time a connection may be reused. zero means forever.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the maximum amount of time a connection may be idle. zero means forever.
+	ConnMaxIdleTime time.Duration
+
+	conn *sql.DB
+	// external is true when the pool was provided by the caller and must not be closed by us.
+	external bool
+	// tx represents the transaction the statements run in, set on the database given to a WithTx function.
+	tx *sql.Tx
+	// parent represents the database a transaction was started from, it holds the schema cache.
+	parent *DB
+
+	schemaMu sync.RWMutex
+	tables   map[string]*Table
 }
 
-// Open opens a database connection.
-func (d *DB) Open(ctx context.Context) (*sql.Conn, error) {
-	db, err := sql.Open(d.Engine, d.URI)
+// NewDBFromPool returns a database that uses the given connection pool.
+// the pool is owned by the caller and will not be closed by DB.Close.
+// if dialect is nil, it is guessed from the pool driver.
+func NewDBFromPool(pool *sql.DB, dialect Dialect) *DB {
+	d := &DB{
+		Dialect:  dialect,
+		conn:     pool,
+		external: true,
+	}
+
+	if dialect != nil {
+		d.Engine = dialect.Name()
+	}
+
+	return d
+}
+
+// Open opens the database connection pool and checks that the database is reachable.
+// calling Open on an already opened database only pings it.
+func (d *DB) Open(ctx context.Context) error {
+	if d.Dialect == nil {
+		var err error
+		if d.conn != nil {
+			d.Dialect, err = dialectForPool(d.conn)
+		} else {
+			d.Dialect, err = dialectForEngine(d.Engine)
+		}
+		if err != nil {
+			return err
+		}
+		d.Engine = d.Dialect.Name()
+	}
+
+	if d.conn == nil {
+		dsn, err := d.Dialect.DataSourceName(d.URI)
+		if err != nil {
+			return err
+		}
+
+		db, err := sql.Open(d.Dialect.DriverName(), dsn)
+		if err != nil {
+			return err
+		}
+
+		if d.MaxOpenConns > 0 {
+			db.SetMaxOpenConns(d.MaxOpenConns)
+		}
+		if d.MaxIdleConns > 0 {
+			db.SetMaxIdleConns(d.MaxIdleConns)
+		}
+		if d.ConnMaxLifetime > 0 {
+			db.SetConnMaxLifetime(d.ConnMaxLifetime)
+		}
+		if d.ConnMaxIdleTime > 0 {
+			db.SetConnMaxIdleTime(d.ConnMaxIdleTime)
+		}
+
+		d.conn = db
+	}
+
+	return d.conn.PingContext(ctx)
+}
+
+// Close closes the database connection pool. pools provided by the caller are left open.
+func (d *DB) Close() error {
+	if d.conn == nil || d.external {
+		return nil
+	}
+
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// querier represents the statement methods shared by a connection pool and a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// querier returns the transaction of the database, or its connection pool.
+func (d *DB) querier() querier {
+	if d.tx != nil {
+		return d.tx
+	}
+
+	return d.conn
+}
+
+// WithTx runs fn with a database whose statements run in a single transaction. the transaction is committed
+// if fn returns nil, and rolled back otherwise.
+func (d *DB) WithTx(ctx context.Context, fn func(tx *DB) error) error {
+	return d.withTx(ctx, nil, fn)
+}
+
+// withTx is WithTx with the options of the transaction, like its isolation level. they are ignored when the
+// database already runs a transaction.
+func (d *DB) withTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *DB) error) error {
+	if d.tx != nil {
+		return fn(d)
+	}
+
+	tx, err := d.conn.BeginTx(ctx, opts)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if err = db.PingContext(ctx); err != nil {
-		return nil, err
+
+	txDB := &DB{
+		URI:      d.URI,
+		Engine:   d.Engine,
+		Dialect:  d.Dialect,
+		conn:     d.conn,
+		external: true,
+		tx:       tx,
+		parent:   d,
+	}
+
+	if err := fn(txDB); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback: %v)", err, rbErr)
+		}
+		return err
 	}
 
-	return db.Conn(ctx)
+	return tx.Commit()
 }
 
 // Row represents a row of a table.
@@ -39,35 +181,143 @@ type Column struct {
 	Type      string
 	Value     any
 	IsPrimary bool
+
+	// DatabaseType represents the column type as reported by the database.
+	DatabaseType string
+	// Nullable reports whether the column accepts null values.
+	Nullable bool
+	// Default represents the column default expression. empty if the column has no default.
+	Default string
+	// HasDefault reports whether the database fills the column when it is omitted,
+	// because of a default expression, an identity or a generated column.
+	HasDefault bool
+	// AutoGenerated reports whether the database always computes the column value,
+	// like serial, identity and generated columns.
+	AutoGenerated bool
+	// MaxLength represents the maximum length of a character column. zero means no limit.
+	MaxLength int
+	// EnumValues represents the allowed values of an enum column, or of a column with a
+	// check (column in (...)) constraint.
+	EnumValues []string
+	// Comment represents the column comment.
+	Comment string
+
+	// Label represents the formatted column name, set by the admin when the row is rendered.
+	Label template.HTML
+	// Display represents the formatted column value, set by the admin when the row is rendered.
+	Display template.HTML
+	// Input represents the form input of the column, set by the admin when a form is rendered.
+	Input template.HTML
+	// Related represents the row referenced by the value of a relation column, set by the admin when the row is rendered.
+	Related *RelatedRow
 }
 
-// GetTableColumenRows returns the rows of a table.
-func (d *DB) GetTableColumenRows(ctx context.Context, tableName, primaryKey string, selectColumns []string) ([]Row, []string, error) {
+// IsNull reports whether the column value is NULL.
+func (c Column) IsNull() bool {
+	return c.Value == nil
+}
+
+// Text returns the column value as shown in lists: empty for NULL, times without their zone,
+// and dates without their time.
+func (c Column) Text() string {
+	if t, ok := c.Value.(time.Time); ok && c.DatabaseType == "date" {
+		return t.Format("2006-01-02")
+	}
+
+	return valueText(c.Value)
+}
+
+// FormValue returns the column value as the value of its form input.
+func (c Column) FormValue() string {
+	return goValueToHTMLValue(c.Type, c.Value)
+}
+
+// Required reports whether a value must be provided for the column in a form.
+func (c Column) Required() bool {
+	return !c.Nullable && !c.HasDefault && !c.IsPrimary && c.Type != "bool"
+}
+
+// ListOptions represents the options used to list the rows of a table.
+type ListOptions struct {
+	// Page is the page number, starting from 1. default is 1.
+	Page int
+	// PageSize is the number of rows per page. zero means all rows.
+	PageSize int
+	// CountTotal requests the total number of rows, ignoring the page.
+	CountTotal bool
+	// SortColumn is the column the rows are ordered by. the caller must validate it. default is no order.
+	SortColumn string
+	// SortDesc orders the rows in descending order.
+	SortDesc bool
+	// Filters narrows the rows. the caller must validate the filtered columns.
+	Filters []FilterValue
+}
+
+// GetTableColumenRows returns a page of the rows of a table, the selected column names and,
+// if opts.CountTotal is set, the total number of rows. a page past the last page is then read as the last page.
+func (d *DB) GetTableColumenRows(ctx context.Context, tableName, primaryKey string, selectColumns []string, opts ListOptions) ([]Row, []string, int, error) {
 	if len(selectColumns) == 0 {
 		selectColumns = []string{"*"}
 	}
 
-	db, err := d.Open(ctx)
+	table, err := d.Table(ctx, tableName)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
-	defer db.Close()
 
-	stmt := fmt.Sprintf("select %s from %s", strings.Join(selectColumns, ","), tableName)
-	rows, err := db.QueryContext(ctx, stmt)
+	q := d.newQuery()
+	where := filterClause(q, opts.Filters)
+	if where != "" {
+		where = " where " + where
+	}
+
+	total := 0
+	if opts.CountTotal {
+		stmt := fmt.Sprintf("select count(*) from %s%s", q.ident(tableName), where)
+		if err := d.querier().QueryRowContext(ctx, stmt, q.args...).Scan(&total); err != nil {
+			return nil, nil, 0, err
+		}
+	}
 
+	stmt := fmt.Sprintf("select %s from %s%s", q.idents(selectColumns), q.ident(tableName), where)
+	if opts.SortColumn != "" {
+		dir := "asc"
+		if opts.SortDesc {
+			dir = "desc"
+		}
+		stmt += fmt.Sprintf(" order by %s %s", q.ident(opts.SortColumn), dir)
+		// keep the order stable between pages when the sort column has duplicates.
+		if primaryKey != "" && opts.SortColumn != primaryKey {
+			stmt += fmt.Sprintf(", %s", q.ident(primaryKey))
+		}
+	}
+	if opts.PageSize > 0 {
+		// pages past the last one are clamped to it, which also keeps the offset from overflowing.
+		page := opts.Page
+		if opts.CountTotal {
+			page = min(page, (total+opts.PageSize-1)/opts.PageSize)
+		}
+		page = min(page, math.MaxInt/opts.PageSize)
+		if page < 1 {
+			page = 1
+		}
+		stmt += " " + d.Dialect.LimitOffset(opts.PageSize, (page-1)*opts.PageSize)
+	}
+
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
+	defer rows.Close()
 
 	columns, err := rows.Columns()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 
 	columnTypes, err := rows.ColumnTypes()
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, 0, err
 	}
 
 	out := make([]Row, 0)
@@ -79,7 +329,7 @@ func (d *DB) GetTableColumenRows(ctx context.Context, tableName, primaryKey stri
 		}
 
 		if err := rows.Scan(values...); err != nil {
-			return nil, nil, err
+			return nil, nil, 0, err
 		}
 
 		row := Row{
@@ -88,37 +338,313 @@ func (d *DB) GetTableColumenRows(ctx context.Context, tableName, primaryKey stri
 		}
 
 		for i, column := range columns {
-			row.Columns = append(row.Columns, Column{
-				Name:      column,
-				Type:      fieldTypeToGo(columnTypes[i].DatabaseTypeName()),
-				Value:     values[i],
-				IsPrimary: column == primaryKey,
-			})
+			row.Columns = append(row.Columns, table.describe(column, d.Dialect.FieldTypeToGo(columnTypes[i].DatabaseTypeName()), primaryKey, values[i]))
 
 			if column == primaryKey {
-				row.PrimaryKeyValue = values[i]
+				row.PrimaryKeyValue = row.Columns[i].Value
 			}
 		}
 
 		out = append(out, row)
 	}
 
-	return out, columns, nil
+	if err := rows.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	return out, columns, total, nil
+}
+
+// SearchEntity returns up to limit rows of a table matching the query in any of the given columns,
+// and the total number of matching rows. the primary key is always selected first.
+// if fullText is set and the dialect supports it, full text search is used instead of a case insensitive substring match.
+// when filters are given, only the rows matching them are searched.
+func (d *DB) SearchEntity(ctx context.Context, tableName, primaryKey string, columns []string, query string, fullText bool, limit int, filters ...FilterValue) ([]Row, int, error) {
+	table, err := d.Table(ctx, tableName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var where string
+	q := d.newQuery()
+
+	if fullText {
+		exprs := make([]string, 0, len(columns))
+		for _, column := range columns {
+			exprs = append(exprs, q.ident(column))
+		}
+		where = d.Dialect.FullTextExpr(exprs, q.arg(query))
+	}
+
+	if where == "" {
+		q = d.newQuery()
+		conds := make([]string, 0, len(columns))
+		for _, column := range columns {
+			conds = append(conds, d.Dialect.ContainsExpr(q.ident(column), q.arg("%"+likeEscaper.Replace(query)+"%")))
+		}
+		where = strings.Join(conds, " or ")
+	}
+	where = "(" + where + ")" + andFilterClause(q, filters)
+
+	total := 0
+	stmt := fmt.Sprintf("select count(*) from %s where %s", q.ident(tableName), where)
+	if err := d.querier().QueryRowContext(ctx, stmt, q.args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	if total == 0 {
+		return nil, 0, nil
+	}
+
+	stmt = fmt.Sprintf("select %s,%s from %s where %s order by %s %s", q.ident(primaryKey), q.idents(columns), q.ident(tableName), where, q.ident(primaryKey), d.Dialect.LimitOffset(limit, 0))
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	out := make([]Row, 0)
+	for rows.Next() {
+		values := make([]any, len(columns)+1)
+		for i := range values {
+			values[i] = &values[i]
+		}
+
+		if err := rows.Scan(values...); err != nil {
+			return nil, 0, err
+		}
+
+		row := Row{
+			PrimaryKey:      primaryKey,
+			PrimaryKeyValue: table.describe(primaryKey, "any", primaryKey, values[0]).Value,
+		}
+		for i, column := range columns {
+			row.Columns = append(row.Columns, table.describe(column, "any", primaryKey, values[i+1]))
+		}
+
+		out = append(out, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return out, total, nil
+}
+
+// LookupResult represents a row matched by a lookup, by its key and its display text.
+type LookupResult struct {
+	ID   string `json:"id"`
+	Text string `json:"text"`
+}
+
+// LookupEntity returns up to limit rows of a table whose display column contains the query, ignoring case,
+// ordered by the display column. an empty query returns the first rows. when filters are given, only the rows
+// matching them are returned.
+func (d *DB) LookupEntity(ctx context.Context, tableName, keyColumn, displayColumn, query string, limit int, filters ...FilterValue) ([]LookupResult, error) {
+	q := d.newQuery()
+	conds := make([]string, 0, 2)
+	if query != "" {
+		conds = append(conds, d.Dialect.ContainsExpr(q.ident(displayColumn), q.arg("%"+likeEscaper.Replace(query)+"%")))
+	}
+	if clause := filterClause(q, filters); clause != "" {
+		conds = append(conds, clause)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " where " + strings.Join(conds, " and ")
+	}
+
+	stmt := fmt.Sprintf("select %s,%s from %s%s order by %s, %s %s", q.ident(keyColumn), q.ident(displayColumn), q.ident(tableName), where,
+		q.ident(displayColumn), q.ident(keyColumn), d.Dialect.LimitOffset(limit, 0))
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]LookupResult, 0)
+	for rows.Next() {
+		var key, display any
+		if err := rows.Scan(&key, &display); err != nil {
+			return nil, err
+		}
+
+		out = append(out, LookupResult{ID: valueText(key), Text: valueText(display)})
+	}
+
+	return out, rows.Err()
 }
 
-// GetEntityByID returns a row of a table by its primary key.
-func (d *DB) GetEntityByID(ctx context.Context, tableName, primaryKey string, editColumns []string, id any) (*Row, error) {
-	db, err := d.Open(ctx)
+// GetDisplayValues returns the display column of the rows of a table whose key column is one of the keys,
+// by the text of their key. when filters are given, only the rows matching them are returned.
+func (d *DB) GetDisplayValues(ctx context.Context, tableName, keyColumn, displayColumn string, keys []any, filters ...FilterValue) (map[string]string, error) {
+	out := make(map[string]string)
+	if len(keys) == 0 {
+		return out, nil
+	}
+
+	q := d.newQuery()
+	placeHolders := make([]string, 0, len(keys))
+	for _, key := range keys {
+		placeHolders = append(placeHolders, q.arg(key))
+	}
+
+	stmt := fmt.Sprintf("select %s,%s from %s where %s in (%s)%s", q.ident(keyColumn), q.ident(displayColumn), q.ident(tableName),
+		q.ident(keyColumn), strings.Join(placeHolders, ","), andFilterClause(q, filters))
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, display any
+		if err := rows.Scan(&key, &display); err != nil {
+			return nil, err
+		}
+
+		out[valueText(key)] = valueText(display)
+	}
+
+	return out, rows.Err()
+}
+
+// JoinTable represents a join table linking the rows of a table to the rows of a target table.
+type JoinTable struct {
+	Name string
+	// LocalKey represents the column referencing the linking row.
+	LocalKey string
+	// RemoteKey represents the column referencing the linked row.
+	RemoteKey string
+}
+
+// GetLinkedRows returns the rows of the target table linked to each of the local keys through the join table,
+// ordered by the display column, by the text of the local key. when filters are given, only the target rows
+// matching them are returned.
+func (d *DB) GetLinkedRows(ctx context.Context, join JoinTable, targetTable, targetKey, displayColumn string, localKeys []any, filters ...FilterValue) (map[string][]LookupResult, error) {
+	out := make(map[string][]LookupResult)
+	if len(localKeys) == 0 {
+		return out, nil
+	}
+
+	q := d.newQuery()
+	placeHolders := make([]string, 0, len(localKeys))
+	for _, key := range localKeys {
+		placeHolders = append(placeHolders, q.arg(key))
+	}
+
+	// the filters name target columns, they are matched in a subquery so they can not be taken for join table columns.
+	var filterCond string
+	if clause := filterClause(q, filters); clause != "" {
+		filterCond = fmt.Sprintf(" and j.%s in (select %s from %s where %s)", q.ident(join.RemoteKey), q.ident(targetKey), q.ident(targetTable), clause)
+	}
 
-	stmt := fmt.Sprintf("select %s from %s where %s = $1 limit 1", strings.Join(editColumns, ","), tableName, primaryKey)
-	rows, err := db.QueryContext(ctx, stmt, id)
+	stmt := fmt.Sprintf("select j.%s, t.%s, t.%s from %s j join %s t on t.%s = j.%s where j.%s in (%s)%s order by t.%s, t.%s",
+		q.ident(join.LocalKey), q.ident(targetKey), q.ident(displayColumn), q.ident(join.Name), q.ident(targetTable),
+		q.ident(targetKey), q.ident(join.RemoteKey), q.ident(join.LocalKey), strings.Join(placeHolders, ","), filterCond,
+		q.ident(displayColumn), q.ident(targetKey))
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var local, key, display any
+		if err := rows.Scan(&local, &key, &display); err != nil {
+			return nil, err
+		}
+
+		out[valueText(local)] = append(out[valueText(local)], LookupResult{ID: valueText(key), Text: valueText(display)})
+	}
+
+	return out, rows.Err()
+}
+
+// GetLinks returns the remote keys the join table links to the local key, by their text.
+func (d *DB) GetLinks(ctx context.Context, join JoinTable, localKey any) (map[string]any, error) {
+	q := d.newQuery()
+	stmt := fmt.Sprintf("select %s from %s where %s = %s", q.ident(join.RemoteKey), q.ident(join.Name), q.ident(join.LocalKey), q.arg(localKey))
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]any)
+	for rows.Next() {
+		var key any
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		out[valueText(key)] = key
+	}
+
+	return out, rows.Err()
+}
+
+// SetLinks makes the join table link the local key to the remote keys only. links that are kept are left as is,
+// removed links are deleted and new links are inserted.
+func (d *DB) SetLinks(ctx context.Context, join JoinTable, localKey any, remoteKeys []any) error {
+	current, err := d.GetLinks(ctx, join, localKey)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(remoteKeys))
+	for _, key := range remoteKeys {
+		text := valueText(key)
+		if wanted[text] {
+			continue
+		}
+		wanted[text] = true
+
+		if _, ok := current[text]; ok {
+			continue
+		}
+
+		q := d.newQuery()
+		stmt := fmt.Sprintf("insert into %s (%s,%s) values (%s,%s)", q.ident(join.Name), q.ident(join.LocalKey), q.ident(join.RemoteKey),
+			q.arg(localKey), q.arg(key))
+		if _, err := d.querier().ExecContext(ctx, stmt, q.args...); err != nil {
+			return err
+		}
+	}
+
+	for text, key := range current {
+		if wanted[text] {
+			continue
+		}
+
+		q := d.newQuery()
+		stmt := fmt.Sprintf("delete from %s where %s = %s and %s = %s", q.ident(join.Name), q.ident(join.LocalKey), q.arg(localKey),
+			q.ident(join.RemoteKey), q.arg(key))
+		if _, err := d.querier().ExecContext(ctx, stmt, q.args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetEntityByID returns a row of a table by its primary key, or sql.ErrNoRows. when filters are given, a row
+// not matching them is not found.
+func (d *DB) GetEntityByID(ctx context.Context, tableName, primaryKey string, editColumns []string, id any, filters ...FilterValue) (*Row, error) {
+	table, err := d.Table(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	q := d.newQuery()
+	stmt := fmt.Sprintf("select %s from %s where %s = %s%s %s", q.idents(editColumns), q.ident(tableName), q.ident(primaryKey), q.arg(id),
+		andFilterClause(q, filters), d.Dialect.LimitOffset(1, 0))
+	rows, err := d.querier().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
 	columns, err := rows.Columns()
 	if err != nil {
@@ -135,10 +661,15 @@ func (d *DB) GetEntityByID(ctx context.Context, tableName, primaryKey string, ed
 		values[i] = &values[i]
 	}
 
-	for rows.Next() {
-		if err := rows.Scan(values...); err != nil {
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
 			return nil, err
 		}
+		return nil, sql.ErrNoRows
+	}
+
+	if err := rows.Scan(values...); err != nil {
+		return nil, err
 	}
 
 	out := &Row{
@@ -147,15 +678,10 @@ func (d *DB) GetEntityByID(ctx context.Context, tableName, primaryKey string, ed
 
 	cols := make([]Column, 0)
 	for i, column := range columns {
-		cols = append(cols, Column{
-			Name:      column,
-			Type:      fieldTypeToGo(columnTypes[i].DatabaseTypeName()),
-			Value:     values[i],
-			IsPrimary: column == primaryKey,
-		})
+		cols = append(cols, table.describe(column, d.Dialect.FieldTypeToGo(columnTypes[i].DatabaseTypeName()), primaryKey, values[i]))
 
 		if column == primaryKey {
-			out.PrimaryKeyValue = values[i]
+			out.PrimaryKeyValue = cols[i].Value
 		}
 	}
 
@@ -164,122 +690,106 @@ func (d *DB) GetEntityByID(ctx context.Context, tableName, primaryKey string, ed
 	return out, nil
 }
 
-// DeleteEntityByID deletes a row of a table by its primary key.
-func (d *DB) DeleteEntityByID(ctx context.Context, tableName, primaryKey string, id any) error {
-	db, err := d.Open(ctx)
-	if err != nil {
-		return err
-	}
-	defer db.Close()
-
-	stmt := fmt.Sprintf("delete from %s where %s = $1", tableName, primaryKey)
-	if _, err := db.ExecContext(ctx, stmt, id); err != nil {
+// DeleteEntityByID deletes a row of a table by its primary key. when filters are given, a row not matching them
+// is left as is.
+func (d *DB) DeleteEntityByID(ctx context.Context, tableName, primaryKey string, id any, filters ...FilterValue) error {
+	q := d.newQuery()
+	stmt := fmt.Sprintf("delete from %s where %s = %s%s", q.ident(tableName), q.ident(primaryKey), q.arg(id), andFilterClause(q, filters))
+	if _, err := d.querier().ExecContext(ctx, stmt, q.args...); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// CreateEntity creates a row of a table.
+// CreateEntity creates a row of a table. the columns must exist in the table, the primary key is skipped.
 func (d *DB) CreateEntity(ctx context.Context, tableName, primaryKey string, columns []Column) error {
-	db, err := d.Open(ctx)
+	table, err := d.Table(ctx, tableName)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
+	if err := checkColumns(table, columns); err != nil {
+		return err
+	}
+
+	q := d.newQuery()
 	cols := make([]string, 0)
-	values := make([]any, 0)
 	placeHolders := make([]string, 0)
 
-	for i, column := range columns {
+	for _, column := range columns {
 		if column.Name == primaryKey {
 			continue
 		}
 
 		cols = append(cols, column.Name)
-		values = append(values, column.Value)
-		placeHolders = append(placeHolders, fmt.Sprintf("$%d", i+1))
+		placeHolders = append(placeHolders, q.arg(column.Value))
 	}
 
-	stmt := fmt.Sprintf("insert into %s (%s) values (%s)", tableName, strings.Join(cols, ","), strings.Join(placeHolders, ","))
-	if _, err := db.ExecContext(ctx, stmt, values...); err != nil {
+	stmt := fmt.Sprintf("insert into %s (%s) values (%s)", q.ident(tableName), q.idents(cols), strings.Join(placeHolders, ","))
+	if _, err := d.querier().ExecContext(ctx, stmt, q.args...); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// UpdateEntity updates a row of a table.
-func (d *DB) UpdateEntity(ctx context.Context, tableName, primaryKey string, primaryKeyValue string, columns []Column) error {
-	db, err := d.Open(ctx)
+// UpdateEntity updates a row of a table by its primary key. the columns must exist in the table, the primary key is skipped.
+// when filters are given, a row not matching them is left as is.
+func (d *DB) UpdateEntity(ctx context.Context, tableName, primaryKey string, primaryKeyValue any, columns []Column, filters ...FilterValue) error {
+	table, err := d.Table(ctx, tableName)
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	values := make([]any, 0)
+	if err := checkColumns(table, columns); err != nil {
+		return err
+	}
+
+	q := d.newQuery()
 	setQueries := make([]string, 0)
 
-	for i, column := range columns {
+	for _, column := range columns {
 		if column.Name == primaryKey {
 			continue
 		}
 
-		setQueries = append(setQueries, fmt.Sprintf("%s = $%d", column.Name, i+1))
-		values = append(values, column.Value)
+		setQueries = append(setQueries, fmt.Sprintf("%s = %s", q.ident(column.Name), q.arg(column.Value)))
+	}
+
+	if len(setQueries) == 0 {
+		return nil
 	}
 
-	stmt := fmt.Sprintf("update %s set %s where %s = %s", tableName, strings.Join(setQueries, ","), primaryKey, primaryKeyValue)
-	_, err = db.ExecContext(ctx, stmt, values...)
+	stmt := fmt.Sprintf("update %s set %s where %s = %s%s", q.ident(tableName), strings.Join(setQueries, ","), q.ident(primaryKey), q.arg(primaryKeyValue),
+		andFilterClause(q, filters))
+	_, err = d.querier().ExecContext(ctx, stmt, q.args...)
 	return err
 }
 
 // GetTableRow returns the columns of a table.
 func (d *DB) GetTableRow(ctx context.Context, tableName, primaryKey string, editColumns []string) (*Row, error) {
-	db, err := d.Open(ctx)
+	table, err := d.Table(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
-	defer db.Close()
 
-	if len(editColumns) == 0 {
-		editColumns = []string{"*"}
+	if len(editColumns) == 0 || slices.Contains(editColumns, "*") {
+		editColumns = table.ColumnNames()
 	}
 
-	rows, err := db.QueryContext(ctx, fmt.Sprintf("select %s from %s limit 0", strings.Join(editColumns, ","), tableName))
-	if err != nil {
-		return nil, err
-	}
-
-	columns, err := rows.ColumnTypes()
-	if err != nil {
-		return nil, err
-	}
-
-	// dummy scan
-	values := make([]any, len(columns))
-	for i := range columns {
-		values[i] = &values[i]
-	}
-
-	for rows.Next() {
-		if err := rows.Scan(values...); err != nil {
-			return nil, err
-		}
-	}
-	// end dummy scan
-
 	out := &Row{
 		PrimaryKey: primaryKey,
 	}
 
-	for _, column := range columns {
-		out.Columns = append(out.Columns, Column{
-			Name:      column.Name(),
-			Type:      fieldTypeToGo(column.DatabaseTypeName()),
-			IsPrimary: column.Name() == primaryKey,
-		})
+	for _, name := range editColumns {
+		column, ok := table.Column(name)
+		if !ok {
+			return nil, fmt.Errorf("column %q does not exist in table %q", name, tableName)
+		}
+
+		column.IsPrimary = name == primaryKey
+		out.Columns = append(out.Columns, column)
 	}
 
 	return out, nil
@@ -287,45 +797,33 @@ func (d *DB) GetTableRow(ctx context.Context, tableName, primaryKey string, edit
 
 // GetTableFieldTypes returns the field types of a table.
 func (d *DB) GetTableFieldTypes(ctx context.Context, tableName string) (map[string]string, error) {
-	db, err := d.Open(ctx)
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
-
-	rows, err := db.QueryContext(ctx, fmt.Sprintf("select * from %s limit 0", tableName))
-	if err != nil {
-		return nil, err
-	}
-
-	columns, err := rows.ColumnTypes()
+	table, err := d.Table(ctx, tableName)
 	if err != nil {
 		return nil, err
 	}
 
 	out := make(map[string]string)
 
-	for _, column := range columns {
-		out[column.Name()] = column.DatabaseTypeName()
+	for _, column := range table.Columns {
+		out[column.Name] = column.DatabaseType
 	}
 
 	return out, nil
 }
 
 func fieldTypeToGo(fieldType string) string {
-	switch fieldType {
-	case "int", "int2", "int4", "int8", "smallint", "integer", "bigint", "INT4":
+	switch strings.ToLower(fieldType) {
+	case "int", "int2", "int4", "int8", "smallint", "integer", "bigint", "serial", "bigserial":
 		return "int"
 	case "float", "float4", "float8", "decimal", "numeric", "real", "double precision":
 		return "float64"
 	case "bool", "boolean":
 		return "bool"
-	case "date", "timestamp", "timestamp with time zone", "timestamp without time zone", "TIMESTAMP":
+	case "date", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone":
 		return "time.Time"
-	case "text", "varchar", "character varying", "character", "TEXT":
+	case "text", "varchar", "bpchar", "character varying", "character", "uuid", "json", "jsonb", "citext":
 		return "string"
 	default:
-		fmt.Println("unknown type", fieldType)
 		return "any"
 	}
 }
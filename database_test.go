@@ -0,0 +1,249 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// newTestStore returns a DB opened on a sqlite database, once the statements are run.
+func newTestStore(t *testing.T, stmts ...string) *DB {
+	t.Helper()
+
+	d := NewDBFromPool(newTestDB(t, stmts...), nil)
+	if err := d.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+// newNotesStore returns a database with the notes table and the given notes, ids starting from 1.
+func newNotesStore(t *testing.T, titles ...string) *DB {
+	d := newTestStore(t, "create table notes (id integer primary key, title text not null, body text, stars integer)")
+	for _, title := range titles {
+		if err := d.CreateEntity(context.Background(), "notes", "id", []Column{{Name: "title", Value: title}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+// rowValues returns the text of the values of the columns of the row, by column name.
+func rowValues(row Row) map[string]string {
+	out := make(map[string]string, len(row.Columns))
+	for _, column := range row.Columns {
+		out[column.Name] = valueText(column.Value)
+	}
+
+	return out
+}
+
+// rowIDs returns the text of the primary keys of the rows.
+func rowIDs(rows []Row) []string {
+	out := make([]string, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, valueText(row.PrimaryKeyValue))
+	}
+
+	return out
+}
+
+func TestDBCRUD(t *testing.T) {
+	ctx := context.Background()
+	d := newNotesStore(t)
+
+	err := d.CreateEntity(ctx, "notes", "id", []Column{{Name: "id", Value: 9}, {Name: "title", Value: "first"}, {Name: "stars", Value: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := d.GetEntityByID(ctx, "notes", "id", []string{"*"}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rowValues(*row), map[string]string{"id": "1", "title": "first", "body": "", "stars": "3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("created row = %v, want %v, the primary key is filled by the database", got, want)
+	}
+	if valueText(row.PrimaryKeyValue) != "1" {
+		t.Errorf("primary key value = %v", row.PrimaryKeyValue)
+	}
+
+	if err := d.UpdateEntity(ctx, "notes", "id", "1", []Column{{Name: "title", Value: "edited"}, {Name: "body", Value: "text"}}); err != nil {
+		t.Fatal(err)
+	}
+	row, err = d.GetEntityByID(ctx, "notes", "id", []string{"title", "body"}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rowValues(*row), map[string]string{"title": "edited", "body": "text"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("updated row = %v, want %v", got, want)
+	}
+
+	if err := d.CreateEntity(ctx, "notes", "id", []Column{{Name: "missing", Value: 1}}); err == nil {
+		t.Error("create with an unknown column: want an error")
+	}
+	if err := d.UpdateEntity(ctx, "notes", "id", "1", []Column{{Name: "title = 'x', body", Value: 1}}); err == nil {
+		t.Error("update with an unknown column: want an error")
+	}
+
+	if err := d.DeleteEntityByID(ctx, "notes", "id", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.GetEntityByID(ctx, "notes", "id", []string{"*"}, "1"); err != sql.ErrNoRows {
+		t.Errorf("deleted row error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestDBLookups(t *testing.T) {
+	ctx := context.Background()
+	d := newTestStore(t,
+		"create table authors (id integer primary key, name text not null)",
+		"insert into authors values (1, 'Zoe'), (2, 'ann'), (3, 'Annie'), (4, '50% Bob')",
+	)
+
+	results, err := d.LookupEntity(ctx, "authors", "id", "name", "AN", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []LookupResult{{ID: "3", Text: "Annie"}, {ID: "2", Text: "ann"}}; !reflect.DeepEqual(results, want) {
+		t.Errorf("lookup = %v, want %v", results, want)
+	}
+
+	results, err = d.LookupEntity(ctx, "authors", "id", "name", "0%", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "4" {
+		t.Errorf("lookup with a wildcard = %v, want only 4", results)
+	}
+
+	results, err = d.LookupEntity(ctx, "authors", "id", "name", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Errorf("empty lookup = %v, want the first 2 rows", results)
+	}
+
+	values, err := d.GetDisplayValues(ctx, "authors", "id", "name", []any{int64(1), "3", int64(9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"1": "Zoe", "3": "Annie"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("display values = %v, want %v", values, want)
+	}
+}
+
+func TestDBFilteredWrites(t *testing.T) {
+	ctx := context.Background()
+	d := newNotesStore(t, "mine", "other")
+	mine := []FilterValue{{Column: "title", Kind: FilterExact, Values: []string{"mine"}}}
+
+	if _, err := d.GetEntityByID(ctx, "notes", "id", []string{"*"}, "2", mine...); err != sql.ErrNoRows {
+		t.Errorf("filtered out row error = %v, want sql.ErrNoRows", err)
+	}
+
+	if err := d.UpdateEntity(ctx, "notes", "id", "2", []Column{{Name: "body", Value: "changed"}}, mine...); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.DeleteEntityByID(ctx, "notes", "id", "2", mine...); err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := d.GetEntityByID(ctx, "notes", "id", []string{"body"}, "2")
+	if err != nil {
+		t.Fatalf("filtered out row was deleted: %v", err)
+	}
+	if body := rowValues(*row)["body"]; body != "" {
+		t.Errorf("filtered out row was updated, body = %q", body)
+	}
+}
+
+func TestDBFilters(t *testing.T) {
+	ctx := context.Background()
+	d := newTestStore(t,
+		"create table notes (id integer primary key, title text not null, stars integer)",
+		`insert into notes values (1, 'Go tips', 5), (2, 'go_lang', null), (3, '100% done', 2), (4, 'misc', 4)`,
+	)
+
+	tests := []struct {
+		name    string
+		filters []FilterValue
+		want    []string
+	}{
+		{"exact", []FilterValue{{Column: "stars", Kind: FilterExact, Values: []string{"5"}}}, []string{"1"}},
+		{"in", []FilterValue{{Column: "stars", Kind: FilterIn, Values: []string{"2", "4"}}}, []string{"3", "4"}},
+		{"empty in", []FilterValue{{Column: "stars", Kind: FilterIn}}, []string{}},
+		{"contains ignores case", []FilterValue{{Column: "title", Kind: FilterContains, Values: []string{"GO"}}}, []string{"1", "2"}},
+		{"contains escapes wildcards", []FilterValue{{Column: "title", Kind: FilterContains, Values: []string{"o_l"}}}, []string{"2"}},
+		{"contains percent", []FilterValue{{Column: "title", Kind: FilterContains, Values: []string{"0%"}}}, []string{"3"}},
+		{"range", []FilterValue{{Column: "stars", Kind: FilterRange, Values: []string{"3", "5"}}}, []string{"1", "4"}},
+		{"open range", []FilterValue{{Column: "stars", Kind: FilterRange, Values: []string{"", "2"}}}, []string{"3"}},
+		{"is null", []FilterValue{{Column: "stars", Kind: FilterIsNull, Values: []string{"true"}}}, []string{"2"}},
+		{"is not null", []FilterValue{{Column: "stars", Kind: FilterIsNull, Values: []string{"false"}}}, []string{"1", "3", "4"}},
+		{"combined", []FilterValue{
+			{Column: "stars", Kind: FilterIsNull, Values: []string{"false"}},
+			{Column: "title", Kind: FilterContains, Values: []string{"o"}},
+		}, []string{"1", "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, _, total, err := d.GetTableColumenRows(ctx, "notes", "id", []string{"id"}, ListOptions{
+				CountTotal: true,
+				SortColumn: "id",
+				Filters:    tt.filters,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := rowIDs(rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+			if total != len(tt.want) {
+				t.Errorf("total = %d, want %d", total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestDBPagination(t *testing.T) {
+	ctx := context.Background()
+	d := newNotesStore(t, "a", "b", "c", "d", "e")
+
+	tests := []struct {
+		name string
+		opts ListOptions
+		want []string
+	}{
+		{"first page", ListOptions{Page: 1, PageSize: 2}, []string{"1", "2"}},
+		{"last page", ListOptions{Page: 3, PageSize: 2}, []string{"5"}},
+		{"page zero", ListOptions{Page: 0, PageSize: 2}, []string{"1", "2"}},
+		{"past the last page", ListOptions{Page: 9, PageSize: 2, CountTotal: true}, []string{"5"}},
+		{"huge page", ListOptions{Page: math.MaxInt, PageSize: 2, CountTotal: true}, []string{"5"}},
+		{"descending", ListOptions{Page: 1, PageSize: 2, SortDesc: true}, []string{"5", "4"}},
+		{"all rows", ListOptions{}, []string{"1", "2", "3", "4", "5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.SortColumn = "id"
+			rows, _, total, err := d.GetTableColumenRows(ctx, "notes", "id", []string{"id", "title"}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := rowIDs(rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rows = %v, want %v", got, tt.want)
+			}
+			if tt.opts.CountTotal && total != 5 {
+				t.Errorf("total = %d, want 5", total)
+			}
+		})
+	}
+}
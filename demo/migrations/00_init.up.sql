@@ -1,32 +1,44 @@
-create table if not exists users (
+create table if not exists organizations (
     id serial primary key,
     name text not null,
-    email text not null unique,
-    password text not null,
     created_at timestamp not null default now(),
     updated_at timestamp not null default now()
 );
 
-create table if not exists permissions (
+create table if not exists users (
     id serial primary key,
     name text not null,
+    organization_id int,
+    email text not null unique,
+    password text not null,
     created_at timestamp not null default now(),
-    updated_at timestamp not null default now()
+    updated_at timestamp not null default now(),
+    foreign key (organization_id) references organizations (id)
 );
 
-create table if not exists organizations (
+create table if not exists permissions (
     id serial primary key,
     name text not null,
     created_at timestamp not null default now(),
     updated_at timestamp not null default now()
 );
 
+create table if not exists user_permissions (
+    user_id int not null,
+    permission_id int not null,
+    primary key (user_id, permission_id),
+    foreign key (user_id) references users (id) on delete cascade,
+    foreign key (permission_id) references permissions (id) on delete cascade
+);
+
 create table if not exists api_keys (
     id serial primary key,
     name text not null,
+    user_id int not null,
     key text not null,
     created_at timestamp not null default now(),
-    updated_at timestamp not null default now()
+    updated_at timestamp not null default now(),
+    foreign key (user_id) references users (id) on delete cascade
 );
 
 create table if not exists settings (
@@ -41,7 +53,7 @@ create table if not exists tasks (
     id serial primary key,
     name text not null,
     description text not null,
-    status text not null,
+    status text not null default 'todo' check (status in ('todo', 'in_progress', 'done')),
     created_at timestamp not null default now(),
     updated_at timestamp not null default now()
 );
\ No newline at end of file
@@ -0,0 +1,59 @@
+create table if not exists organizations (
+    id int auto_increment primary key,
+    name varchar(255) not null,
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp
+);
+
+create table if not exists users (
+    id int auto_increment primary key,
+    name varchar(255) not null,
+    organization_id int,
+    email varchar(255) not null unique,
+    password varchar(255) not null,
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp,
+    foreign key (organization_id) references organizations (id)
+);
+
+create table if not exists permissions (
+    id int auto_increment primary key,
+    name varchar(255) not null,
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp
+);
+
+create table if not exists user_permissions (
+    user_id int not null,
+    permission_id int not null,
+    primary key (user_id, permission_id),
+    foreign key (user_id) references users (id) on delete cascade,
+    foreign key (permission_id) references permissions (id) on delete cascade
+);
+
+create table if not exists api_keys (
+    id int auto_increment primary key,
+    name varchar(255) not null,
+    user_id int not null,
+    `key` varchar(255) not null,
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp,
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+create table if not exists settings (
+    id int auto_increment primary key,
+    name varchar(255) not null,
+    value text not null,
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp
+);
+
+create table if not exists tasks (
+    id int auto_increment primary key,
+    name varchar(255) not null,
+    description text not null,
+    status varchar(32) not null default 'todo' check (status in ('todo', 'in_progress', 'done')),
+    created_at timestamp not null default current_timestamp,
+    updated_at timestamp not null default current_timestamp
+);
@@ -0,0 +1,158 @@
+package crud
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// detailRelatedLimit is the number of child rows of an inline listed on the detail page.
+const detailRelatedLimit = 20
+
+// getEntityDetail renders the detail page of a row, with every column, the referenced rows and the rows
+// linked to it. the edit and delete buttons are shown when the user may run those actions.
+func (a *Admin) getEntityDetail(w http.ResponseWriter, r *http.Request) {
+	entityName := chi.URLParam(r, "entity")
+	entityID := chi.URLParam(r, "entityID")
+
+	entity, ok := a.Entities[entityName]
+	if !ok {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+
+	scope, err := a.scope(r.Context(), entity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	row, err := a.db.GetEntityByID(r.Context(), entity.TableName, entity.PrimaryKey, []string{"*"}, entityID, scope...)
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+
+	if err := a.loadRelated(r, entity, []Row{*row}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := DetailData{
+		Title:       entity.TitleSingular,
+		Description: entity.Description,
+		EntityName:  entityName,
+		EntityID:    entityID,
+		Row:         a.detailRow(entity, *row),
+		CanEdit:     a.can(r, entityName, "update"),
+		CanDelete:   a.can(r, entityName, "delete"),
+
+		BaseContextData: a.getBaseContextData(r),
+	}
+
+	data.Related, err = a.getDetailRelated(r, entity, entityID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := a.executeTemplate(w, "detail", data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// detailRow formats a row for the detail page. the values of the columns with a password widget are left out.
+func (a *Admin) detailRow(entity Entity, row Row) Row {
+	row = a.formatRow(entity, row)
+
+	for i, column := range row.Columns {
+		if _, ok := entity.getWidget(column).(PasswordWidget); ok && !column.IsNull() {
+			row.Columns[i].Display = `<span class="text-muted">••••••</span>`
+		}
+	}
+
+	return row
+}
+
+// getDetailRelated returns the rows linked to a row through the many to many relations and the child rows
+// of the inlines of its entity. the rows of the entities the user may not read are left out.
+func (a *Admin) getDetailRelated(r *http.Request, entity Entity, id string) ([]RelatedGroup, error) {
+	ctx := r.Context()
+	out := make([]RelatedGroup, 0, len(entity.ManyToMany)+len(entity.Inlines))
+
+	for _, m := range a.readableManyToMany(r, entity) {
+		target := a.Entities[m.Entity]
+		scope, err := a.scope(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+
+		linked, err := a.db.GetLinkedRows(ctx, m.join(), target.TableName, target.PrimaryKey, m.DisplayColumn, []any{id}, scope...)
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", m.Name, err)
+		}
+
+		group := RelatedGroup{Title: m.Title, Total: len(linked[id])}
+		for _, row := range linked[id] {
+			group.Rows = append(group.Rows, RelatedRow{URL: a.detailURL(target, row.ID), Text: row.Text})
+		}
+		out = append(out, group)
+	}
+
+	for _, inline := range entity.Inlines {
+		child := a.Entities[inline.Entity]
+		if !a.can(r, child.TableName, "read") {
+			continue
+		}
+
+		table, err := a.db.Table(ctx, child.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		scope, err := a.scope(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+
+		display := displayColumn(child, table)
+		rows, _, total, err := a.db.GetTableColumenRows(ctx, child.TableName, child.PrimaryKey, []string{child.PrimaryKey, display}, ListOptions{
+			PageSize:   detailRelatedLimit,
+			CountTotal: true,
+			SortColumn: child.PrimaryKey,
+			Filters:    append(scope, FilterValue{Column: inline.Column, Kind: FilterExact, Values: []string{id}}),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", inline.Entity, err)
+		}
+
+		group := RelatedGroup{Title: inline.Title, Total: total}
+		for _, row := range rows {
+			key := valueText(row.PrimaryKeyValue)
+			text := key
+			for _, column := range row.Columns {
+				if column.Name == display {
+					text = column.Text()
+				}
+			}
+			group.Rows = append(group.Rows, RelatedRow{URL: a.detailURL(child, key), Text: text})
+		}
+		out = append(out, group)
+	}
+
+	return out, nil
+}
+
+// detailURL returns the detail page of a row of an entity.
+func (a *Admin) detailURL(entity Entity, id string) string {
+	return path.Join(a.BaseURL, "/entity/", entity.TableName, url.PathEscape(id))
+}
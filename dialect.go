@@ -0,0 +1,340 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect represents the SQL flavour and the catalog of a database engine.
+type Dialect interface {
+	// Name returns the engine name, like "postgres".
+	Name() string
+	// DriverName returns the database/sql driver name.
+	DriverName() string
+	// DataSourceName converts a database uri to the data source name of the driver.
+	DataSourceName(uri string) (string, error)
+
+	// Placeholder returns the placeholder of the n-th query argument, starting from 1.
+	Placeholder(n int) string
+	// QuoteIdent quotes an identifier. a name qualified with its schema name is quoted part by part.
+	QuoteIdent(name string) string
+	// LimitOffset returns the clause that skips offset rows and returns at most limit rows.
+	LimitOffset(limit, offset int) string
+	// ContainsExpr returns a case insensitive pattern match of an expression against a like pattern.
+	ContainsExpr(expr, placeholder string) string
+	// FullTextExpr returns a full text match of the expressions against a query,
+	// or an empty string if the engine has no full text search without an index.
+	FullTextExpr(exprs []string, placeholder string) string
+	// FieldTypeToGo maps a database type name to a go type name.
+	FieldTypeToGo(fieldType string) string
+
+	// Tables returns the names of the tables and views of a schema, in name order.
+	// if schema is empty the current schema is used. names outside the current schema are qualified with their schema name.
+	Tables(ctx context.Context, db *sql.DB, schema string) ([]string, error)
+	// Table returns the schema of a table, or ErrTableNotFound.
+	Table(ctx context.Context, db *sql.DB, name string) (*Table, error)
+}
+
+// dialects represents the built-in dialects by uri scheme.
+var dialects = map[string]Dialect{
+	"postgres":   postgresDialect{},
+	"postgresql": postgresDialect{},
+	"mysql":      mysqlDialect{},
+	"mariadb":    mysqlDialect{},
+	"sqlite":     sqliteDialect{},
+	"sqlite3":    sqliteDialect{},
+	"file":       sqliteDialect{},
+}
+
+// dialectForURI returns the dialect of a database uri, picked from its scheme.
+// uris without a scheme, like postgres key value connection strings, use the postgres dialect.
+func dialectForURI(uri string) (Dialect, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme == "" {
+		return postgresDialect{}, nil
+	}
+
+	// file: uris of sqlite have no authority part.
+	if !strings.Contains(uri, "://") && !strings.EqualFold(u.Scheme, "file") {
+		return postgresDialect{}, nil
+	}
+
+	d, ok := dialects[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database uri scheme %q", u.Scheme)
+	}
+
+	return d, nil
+}
+
+// dialectForEngine returns the dialect of an engine name.
+func dialectForEngine(engine string) (Dialect, error) {
+	d, ok := dialects[strings.ToLower(engine)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database engine %q", engine)
+	}
+
+	return d, nil
+}
+
+// dialectForPool guesses the dialect of a connection pool from its driver package.
+func dialectForPool(pool *sql.DB) (Dialect, error) {
+	pkg := reflect.Indirect(reflect.ValueOf(pool.Driver())).Type().PkgPath()
+
+	switch {
+	case strings.HasSuffix(pkg, "lib/pq"), strings.Contains(pkg, "jackc/pgx"):
+		return postgresDialect{}, nil
+	case strings.HasSuffix(pkg, "go-sql-driver/mysql"):
+		return mysqlDialect{}, nil
+	case strings.HasSuffix(pkg, "mattn/go-sqlite3"), strings.HasPrefix(pkg, "modernc.org/sqlite"):
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unknown database driver %q, set the dialect with WithDialect", pkg)
+	}
+}
+
+// quoteIdent quotes each part of a qualified name with the given quote, doubling quotes inside names.
+func quoteIdent(name, quote string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// foreignKeyRow represents a row of a foreign key query, one per key column.
+type foreignKeyRow struct {
+	name      string
+	column    string
+	refTable  string
+	refColumn string
+}
+
+// groupForeignKeys groups the key columns of foreign key rows ordered by constraint name and column position.
+func groupForeignKeys(rows []foreignKeyRow) []ForeignKey {
+	out := make([]ForeignKey, 0)
+	for _, row := range rows {
+		if n := len(out); n > 0 && out[n-1].Name == row.name {
+			out[n-1].Columns = append(out[n-1].Columns, row.column)
+			out[n-1].RefColumns = append(out[n-1].RefColumns, row.refColumn)
+			continue
+		}
+
+		out = append(out, ForeignKey{
+			Name:       row.name,
+			Columns:    []string{row.column},
+			RefTable:   row.refTable,
+			RefColumns: []string{row.refColumn},
+		})
+	}
+
+	return out
+}
+
+// queryForeignKeys runs a query returning the constraint name, column, referenced table and
+// referenced column of foreign keys, ordered by constraint name and column position.
+func queryForeignKeys(ctx context.Context, db *sql.DB, query string, args ...any) ([]ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]foreignKeyRow, 0)
+	for rows.Next() {
+		var key foreignKeyRow
+		if err := rows.Scan(&key.name, &key.column, &key.refTable, &key.refColumn); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groupForeignKeys(keys), nil
+}
+
+// checkChoices returns the column and the allowed values of a check constraint of the form
+// col in ('a', 'b'). postgres reports it as col = any (array['a', 'b']), with casts.
+// constraints combining conditions are ignored.
+func checkChoices(clause string) (string, []string, bool) {
+	lower := strings.ToLower(clause)
+	bare := unquoted(lower)
+	if strings.Contains(bare, " and ") || strings.Contains(bare, " or ") || strings.Contains(bare, " not ") {
+		return "", nil, false
+	}
+
+	end, start := strings.Index(bare, " in ("), 0
+	if end >= 0 {
+		start = end + len(" in (")
+	} else if end = strings.Index(bare, "= any ("); end >= 0 {
+		start = end + len("= any (")
+	} else {
+		return "", nil, false
+	}
+
+	column := strings.TrimSpace(clause[:end])
+	if strings.HasPrefix(strings.ToLower(column), "check") {
+		column = column[len("check"):]
+	}
+	if i := strings.Index(column, "::"); i >= 0 {
+		column = column[:i]
+	}
+	column = strings.Trim(column, " ()\"`[]")
+	if i := strings.LastIndex(column, "."); i >= 0 {
+		column = strings.Trim(column[i+1:], "\"`[]")
+	}
+
+	values := listValues(clause[start:])
+	if column == "" || len(values) == 0 {
+		return "", nil, false
+	}
+
+	return column, values, true
+}
+
+// listValues returns the literals of a value list, up to the end of the list. string literals may have a
+// charset prefix and values may have a cast, like _utf8mb4'a' or 'a'::text. it returns nothing if an item
+// is not a string or a number.
+func listValues(list string) []string {
+	list = strings.TrimLeft(list, " (")
+	if strings.HasPrefix(strings.ToLower(list), "array[") {
+		list = list[len("array["):]
+	}
+
+	out := make([]string, 0)
+	for i := 0; i < len(list); {
+		for i < len(list) && list[i] == ' ' {
+			i++
+		}
+		if i < len(list) && list[i] == '_' {
+			for i < len(list) && list[i] != '\'' {
+				i++
+			}
+		}
+
+		start := i
+		quoted := false
+		for ; i < len(list); i++ {
+			c := list[i]
+			if c == '\'' {
+				quoted = !quoted
+			}
+			if !quoted && (c == ',' || c == ')' || c == ']') {
+				break
+			}
+		}
+
+		item := strings.TrimSpace(list[start:i])
+		if j := strings.Index(unquoted(item), "::"); j >= 0 {
+			item = strings.TrimSpace(item[:j])
+		}
+
+		if strings.HasPrefix(item, "'") {
+			literals := quotedLiterals(item)
+			if len(literals) != 1 {
+				return nil
+			}
+			out = append(out, literals[0])
+		} else if _, err := strconv.ParseFloat(item, 64); err == nil {
+			out = append(out, item)
+		} else {
+			return nil
+		}
+
+		if i >= len(list) || list[i] != ',' {
+			break
+		}
+		i++
+	}
+
+	return out
+}
+
+// quotedLiterals returns the single quoted string literals of a sql expression.
+func quotedLiterals(expr string) []string {
+	out := make([]string, 0)
+	var value strings.Builder
+	quoted := false
+
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '\'' && quoted && i+1 < len(expr) && expr[i+1] == '\'':
+			value.WriteByte('\'')
+			i++
+		case c == '\'':
+			quoted = !quoted
+			if !quoted {
+				out = append(out, value.String())
+				value.Reset()
+			}
+		case quoted:
+			value.WriteByte(c)
+		}
+	}
+
+	return out
+}
+
+// unquoted blanks the single quoted string literals of a sql expression, so keywords are only found outside them.
+func unquoted(expr string) string {
+	out := []byte(expr)
+	quoted := false
+	for i := range out {
+		if out[i] == '\'' {
+			quoted = !quoted
+			continue
+		}
+		if quoted {
+			out[i] = ' '
+		}
+	}
+
+	return string(out)
+}
+
+// setCheckChoices sets the allowed values of the columns from their check constraints.
+// enum values reported by the engine are kept.
+func setCheckChoices(table *Table, clauses []string) {
+	for _, clause := range clauses {
+		name, values, ok := checkChoices(clause)
+		if !ok {
+			continue
+		}
+
+		for i, column := range table.Columns {
+			if column.Name == name && len(column.EnumValues) == 0 {
+				table.Columns[i].EnumValues = values
+			}
+		}
+	}
+}
+
+// queryStrings runs a query returning a single text column.
+func queryStrings(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]string, 0)
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		out = append(out, value)
+	}
+
+	return out, rows.Err()
+}
@@ -0,0 +1,205 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// newMySQLTestDB returns the mysql database of the CRUD_MYSQL_URI environment variable, once the statements are
+// run. the test is skipped when it is not set. the statements should drop the tables they create.
+func newMySQLTestDB(t *testing.T, stmts ...string) *sql.DB {
+	t.Helper()
+
+	uri := os.Getenv("CRUD_MYSQL_URI")
+	if uri == "" {
+		t.Skip("CRUD_MYSQL_URI is not set")
+	}
+
+	dsn, err := (mysqlDialect{}).DataSourceName(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	return db
+}
+
+func TestMySQLDataSourceName(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"mysql://root@localhost/app", "root@tcp(localhost:3306)/app?parseTime=true"},
+		{"mariadb://u:p%40ss@db:3307/app", "u:p@ss@tcp(db:3307)/app?parseTime=true"},
+		{"mysql://u@db/app?tls=skip-verify&loc=Local&timeout=5s", "u@tcp(db:3306)/app?loc=Local&parseTime=true&timeout=5s&tls=skip-verify"},
+		{"mysql://u@db/app?charset=utf8mb4&time_zone=%27%2B00%3A00%27", "u@tcp(db:3306)/app?parseTime=true&charset=utf8mb4&time_zone=%27%2B00%3A00%27"},
+	}
+
+	for _, tt := range tests {
+		got, err := (mysqlDialect{}).DataSourceName(tt.uri)
+		if err != nil {
+			t.Errorf("%s: %v", tt.uri, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: data source name = %s, want %s", tt.uri, got, tt.want)
+		}
+	}
+
+	for _, uri := range []string{"mysql://u@db/app?tls=bogus", "mysql://u@db/app?timeout=soon"} {
+		if _, err := (mysqlDialect{}).DataSourceName(uri); err == nil {
+			t.Errorf("%s: want an error", uri)
+		}
+	}
+}
+
+func TestMySQLFieldTypeToGo(t *testing.T) {
+	for fieldType, want := range map[string]string{
+		"tinyint(1)":      "bool",
+		"boolean":         "bool",
+		"bit":             "bool",
+		"tinyint":         "int",
+		"INT":             "int",
+		"unsigned bigint": "int",
+		"year":            "int",
+		"decimal":         "float64",
+		"double":          "float64",
+		"date":            "time.Time",
+		"datetime":        "time.Time",
+		"timestamp":       "time.Time",
+		"varchar":         "string",
+		"longtext":        "string",
+		"enum":            "string",
+		"json":            "string",
+		"time":            "string",
+		"blob":            "any",
+		"geometry":        "any",
+	} {
+		if got := (mysqlDialect{}).FieldTypeToGo(fieldType); got != want {
+			t.Errorf("%q: go type = %s, want %s", fieldType, got, want)
+		}
+	}
+}
+
+func TestMySQLTable(t *testing.T) {
+	pool := newMySQLTestDB(t,
+		"drop view if exists crud_test_active",
+		"drop table if exists crud_test_users",
+		"drop table if exists crud_test_teams",
+		"create table crud_test_teams (id int primary key auto_increment, name text not null)",
+		`create table crud_test_users (
+			id int primary key auto_increment,
+			team_id int,
+			name varchar(50) not null,
+			email text,
+			active boolean not null default true,
+			role enum('admin', 'editor'),
+			foreign key (team_id) references crud_test_teams (id)
+		)`,
+		"create view crud_test_active as select id, name from crud_test_users where active",
+	)
+	t.Cleanup(func() {
+		for _, stmt := range []string{"drop view crud_test_active", "drop table crud_test_users", "drop table crud_test_teams"} {
+			pool.Exec(stmt)
+		}
+	})
+	ctx := context.Background()
+	d := mysqlDialect{}
+
+	names, err := d.Tables(ctx, pool, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"crud_test_active", "crud_test_teams", "crud_test_users"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("tables = %v, want %s", names, name)
+		}
+	}
+
+	table, err := d.Table(ctx, pool, "crud_test_users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.IsView || !reflect.DeepEqual(table.PrimaryKey, []string{"id"}) {
+		t.Errorf("users: view = %v, primary key = %v", table.IsView, table.PrimaryKey)
+	}
+	if want := []string{"id", "team_id", "name", "email", "active", "role"}; !reflect.DeepEqual(table.ColumnNames(), want) {
+		t.Errorf("users columns = %v, want %v", table.ColumnNames(), want)
+	}
+
+	id, _ := table.Column("id")
+	if id.Type != "int" || !id.IsPrimary || !id.AutoGenerated || id.Nullable {
+		t.Errorf("id = %+v, want an auto generated int primary key", id)
+	}
+
+	name, _ := table.Column("name")
+	if name.Type != "string" || name.MaxLength != 50 || name.Nullable {
+		t.Errorf("name = %+v, want a not null string of 50", name)
+	}
+
+	active, _ := table.Column("active")
+	if active.Type != "bool" || !active.HasDefault {
+		t.Errorf("active = %+v, want a bool with a default", active)
+	}
+
+	role, _ := table.Column("role")
+	if !role.Nullable || !reflect.DeepEqual(role.EnumValues, []string{"admin", "editor"}) {
+		t.Errorf("role = %+v, want a nullable enum of admin and editor", role)
+	}
+
+	if len(table.ForeignKeys) != 1 || table.ForeignKeys[0].RefTable != "crud_test_teams" ||
+		!reflect.DeepEqual(table.ForeignKeys[0].Columns, []string{"team_id"}) {
+		t.Errorf("foreign keys = %+v, want team_id referencing crud_test_teams", table.ForeignKeys)
+	}
+
+	view, err := d.Table(ctx, pool, "crud_test_active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !view.IsView {
+		t.Error("crud_test_active is not a view")
+	}
+
+	if _, err := d.Table(ctx, pool, "crud_test_missing"); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("missing table error = %v, want ErrTableNotFound", err)
+	}
+}
+
+func TestMySQLPagination(t *testing.T) {
+	pool := newMySQLTestDB(t,
+		"drop table if exists crud_test_notes",
+		"create table crud_test_notes (id int primary key auto_increment, title text not null)",
+		"insert into crud_test_notes (title) values ('a'), ('b'), ('c'), ('d'), ('e')",
+	)
+	t.Cleanup(func() { pool.Exec("drop table crud_test_notes") })
+
+	d := NewDBFromPool(pool, mysqlDialect{})
+	if err := d.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, _, total, err := d.GetTableColumenRows(context.Background(), "crud_test_notes", "id", []string{"id", "title"},
+		ListOptions{Page: 2, PageSize: 2, SortColumn: "id", CountTotal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rowIDs(rows); !reflect.DeepEqual(got, []string{"3", "4"}) || total != 5 {
+		t.Errorf("rows = %v of %d, want [3 4] of 5", got, total)
+	}
+}
@@ -0,0 +1,211 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// postgresDialect represents the postgres dialect.
+type postgresDialect struct{}
+
+// Name returns the engine name.
+func (postgresDialect) Name() string { return "postgres" }
+
+// DriverName returns the database/sql driver name.
+func (postgresDialect) DriverName() string { return "postgres" }
+
+// DataSourceName returns the uri, lib/pq accepts it as is.
+func (postgresDialect) DataSourceName(uri string) (string, error) { return uri, nil }
+
+// Placeholder returns $n.
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+// QuoteIdent quotes an identifier with double quotes.
+func (postgresDialect) QuoteIdent(name string) string { return quoteIdent(name, `"`) }
+
+// LimitOffset returns the limit and offset clause.
+func (postgresDialect) LimitOffset(limit, offset int) string {
+	return fmt.Sprintf("limit %d offset %d", limit, offset)
+}
+
+// ContainsExpr returns a case insensitive match of the expression text.
+func (postgresDialect) ContainsExpr(expr, placeholder string) string {
+	return fmt.Sprintf("%s::text ilike %s", expr, placeholder)
+}
+
+// FullTextExpr matches the expressions against a plain text search query with the simple configuration.
+func (postgresDialect) FullTextExpr(exprs []string, placeholder string) string {
+	return fmt.Sprintf("to_tsvector('simple', concat_ws(' ', %s)) @@ plainto_tsquery('simple', %s)", strings.Join(exprs, ","), placeholder)
+}
+
+// FieldTypeToGo maps a postgres type name to a go type name.
+func (postgresDialect) FieldTypeToGo(fieldType string) string {
+	return fieldTypeToGo(fieldType)
+}
+
+// Tables returns the names of the tables and views of a schema.
+func (p postgresDialect) Tables(ctx context.Context, db *sql.DB, schema string) ([]string, error) {
+	return queryStrings(ctx, db, `
+		select case when table_schema = current_schema() then table_name else table_schema || '.' || table_name end
+		from information_schema.tables
+		where table_schema = coalesce(nullif($1, ''), current_schema()) and table_type in ('BASE TABLE', 'VIEW')
+		order by table_name`,
+		schema)
+}
+
+// Table returns the schema of a table read from information_schema and pg_catalog.
+func (p postgresDialect) Table(ctx context.Context, db *sql.DB, tableName string) (*Table, error) {
+	schema, name := splitTableName(tableName)
+
+	table := &Table{Name: name}
+
+	var tableType string
+	var comment sql.NullString
+	err := db.QueryRowContext(ctx, `
+		select t.table_schema, t.table_type, obj_description(c.oid, 'pg_class')
+		from information_schema.tables t
+		join pg_catalog.pg_namespace n on n.nspname = t.table_schema
+		join pg_catalog.pg_class c on c.relnamespace = n.oid and c.relname = t.table_name
+		where t.table_schema = coalesce(nullif($1, ''), current_schema()) and t.table_name = $2`,
+		schema, name).Scan(&table.Schema, &tableType, &comment)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, tableName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table.IsView = tableType == "VIEW"
+	table.Comment = comment.String
+
+	rows, err := db.QueryContext(ctx, `
+		select c.column_name, c.data_type, c.udt_schema, c.udt_name, c.is_nullable = 'YES', c.column_default,
+			c.is_identity = 'YES' or c.is_generated = 'ALWAYS', c.character_maximum_length,
+			col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position::int)
+		from information_schema.columns c
+		where c.table_schema = $1 and c.table_name = $2
+		order by c.ordinal_position`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enumTypes := make(map[int]string)
+
+	for rows.Next() {
+		var column Column
+		var dataType, udtSchema, udtName string
+		var def, comment sql.NullString
+		var maxLength sql.NullInt64
+
+		if err := rows.Scan(&column.Name, &dataType, &udtSchema, &udtName, &column.Nullable, &def, &column.AutoGenerated, &maxLength, &comment); err != nil {
+			return nil, err
+		}
+
+		column.DatabaseType = dataType
+		if dataType == "USER-DEFINED" {
+			column.DatabaseType = udtName
+			enumTypes[len(table.Columns)] = udtSchema + "." + udtName
+		}
+
+		column.Type = p.FieldTypeToGo(column.DatabaseType)
+		column.Default = def.String
+		column.AutoGenerated = column.AutoGenerated || strings.HasPrefix(def.String, "nextval(")
+		column.HasDefault = def.Valid || column.AutoGenerated
+		column.MaxLength = int(maxLength.Int64)
+		column.Comment = comment.String
+
+		table.Columns = append(table.Columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, enumType := range enumTypes {
+		values, err := p.enumValues(ctx, db, enumType)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(values) > 0 {
+			table.Columns[i].EnumValues = values
+			table.Columns[i].Type = "string"
+		}
+	}
+
+	checks, err := queryStrings(ctx, db, `
+		select pg_get_constraintdef(con.oid)
+		from pg_catalog.pg_constraint con
+		join pg_catalog.pg_class c on c.oid = con.conrelid
+		join pg_catalog.pg_namespace n on n.oid = c.relnamespace
+		where con.contype = 'c' and n.nspname = $1 and c.relname = $2 and array_length(con.conkey, 1) = 1`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+	setCheckChoices(table, checks)
+
+	table.PrimaryKey, err = p.primaryKey(ctx, db, table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	table.ForeignKeys, err = queryForeignKeys(ctx, db, `
+		select con.conname, a.attname,
+			case when fn.nspname = current_schema() then fc.relname else fn.nspname || '.' || fc.relname end,
+			fa.attname
+		from pg_catalog.pg_constraint con
+		join pg_catalog.pg_class c on c.oid = con.conrelid
+		join pg_catalog.pg_namespace n on n.oid = c.relnamespace
+		join pg_catalog.pg_class fc on fc.oid = con.confrelid
+		join pg_catalog.pg_namespace fn on fn.oid = fc.relnamespace
+		cross join lateral unnest(con.conkey, con.confkey) with ordinality as k(col, ref, position)
+		join pg_catalog.pg_attribute a on a.attrelid = con.conrelid and a.attnum = k.col
+		join pg_catalog.pg_attribute fa on fa.attrelid = con.confrelid and fa.attnum = k.ref
+		where con.contype = 'f' and n.nspname = $1 and c.relname = $2
+		order by con.conname, k.position`,
+		table.Schema, table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, column := range table.Columns {
+		for _, key := range table.PrimaryKey {
+			if column.Name == key {
+				table.Columns[i].IsPrimary = true
+			}
+		}
+	}
+
+	return table, nil
+}
+
+// enumValues returns the labels of a postgres enum type, or nothing if the type is not an enum.
+func (p postgresDialect) enumValues(ctx context.Context, db *sql.DB, typeName string) ([]string, error) {
+	schema, name := splitTableName(typeName)
+
+	return queryStrings(ctx, db, `
+		select e.enumlabel
+		from pg_catalog.pg_enum e
+		join pg_catalog.pg_type t on t.oid = e.enumtypid
+		join pg_catalog.pg_namespace n on n.oid = t.typnamespace
+		where n.nspname = $1 and t.typname = $2
+		order by e.enumsortorder`,
+		schema, name)
+}
+
+// primaryKey returns the primary key columns of a table in key order.
+func (p postgresDialect) primaryKey(ctx context.Context, db *sql.DB, schema, tableName string) ([]string, error) {
+	return queryStrings(ctx, db, `
+		select kcu.column_name
+		from information_schema.table_constraints tc
+		join information_schema.key_column_usage kcu
+			on kcu.constraint_schema = tc.constraint_schema and kcu.constraint_name = tc.constraint_name
+		where tc.constraint_type = 'PRIMARY KEY' and tc.table_schema = $1 and tc.table_name = $2
+		order by kcu.ordinal_position`,
+		schema, tableName)
+}
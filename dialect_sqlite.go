@@ -0,0 +1,310 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteDialect represents the sqlite dialect.
+type sqliteDialect struct{}
+
+// Name returns the engine name.
+func (sqliteDialect) Name() string { return "sqlite" }
+
+// DriverName returns the database/sql driver name.
+func (sqliteDialect) DriverName() string { return "sqlite3" }
+
+// DataSourceName converts a sqlite://path uri to the file path, sqlite:///tmp/app.db is an absolute path
+// and sqlite://app.db a relative one. file: uris are passed to the driver as is.
+func (sqliteDialect) DataSourceName(uri string) (string, error) {
+	for _, scheme := range []string{"sqlite://", "sqlite3://"} {
+		if len(uri) >= len(scheme) && strings.EqualFold(uri[:len(scheme)], scheme) {
+			return uri[len(scheme):], nil
+		}
+	}
+
+	return uri, nil
+}
+
+// Placeholder returns ?.
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+// QuoteIdent quotes an identifier with double quotes.
+func (sqliteDialect) QuoteIdent(name string) string { return quoteIdent(name, `"`) }
+
+// LimitOffset returns the limit and offset clause.
+func (sqliteDialect) LimitOffset(limit, offset int) string {
+	return fmt.Sprintf("limit %d offset %d", limit, offset)
+}
+
+// ContainsExpr returns a match of the expression text. sqlite like ignores the case of ascii letters
+// and has no default escape character.
+func (sqliteDialect) ContainsExpr(expr, placeholder string) string {
+	return fmt.Sprintf(`cast(%s as text) like %s escape '\'`, expr, placeholder)
+}
+
+// FullTextExpr returns an empty string, sqlite full text search needs a fts virtual table.
+func (sqliteDialect) FullTextExpr([]string, string) string { return "" }
+
+// FieldTypeToGo maps a declared sqlite column type to a go type name, following the sqlite type
+// affinity rules. the date, time and boolean names the driver knows are mapped first.
+func (sqliteDialect) FieldTypeToGo(fieldType string) string {
+	fieldType = strings.ToLower(fieldType)
+
+	name := fieldType
+	if i := strings.Index(name, "("); i >= 0 {
+		name = strings.TrimSpace(name[:i])
+	}
+
+	switch name {
+	case "bool", "boolean":
+		return "bool"
+	case "date", "datetime", "timestamp":
+		return "time.Time"
+	}
+
+	switch {
+	case strings.Contains(fieldType, "int"):
+		return "int"
+	case strings.Contains(fieldType, "char"), strings.Contains(fieldType, "clob"), strings.Contains(fieldType, "text"):
+		return "string"
+	case fieldType == "", strings.Contains(fieldType, "blob"):
+		return "any"
+	default:
+		// real and numeric affinity.
+		return "float64"
+	}
+}
+
+// Tables returns the names of the tables and views of a schema, the main schema if schema is empty.
+func (s sqliteDialect) Tables(ctx context.Context, db *sql.DB, schema string) ([]string, error) {
+	if schema == "" {
+		schema = "main"
+	}
+
+	names, err := queryStrings(ctx, db, fmt.Sprintf(`
+		select name from %s.sqlite_master
+		where type in ('table', 'view') and name not like 'sqlite\_%%' escape '\'
+		order by name`,
+		s.QuoteIdent(schema)))
+	if err != nil {
+		return nil, err
+	}
+
+	if schema != "main" {
+		for i, name := range names {
+			names[i] = schema + "." + name
+		}
+	}
+
+	return names, nil
+}
+
+// Table returns the schema of a table read with the table_info and foreign_key_list pragmas.
+func (s sqliteDialect) Table(ctx context.Context, db *sql.DB, tableName string) (*Table, error) {
+	schema, name := splitTableName(tableName)
+	if schema == "" {
+		schema = "main"
+	}
+
+	table := &Table{Schema: schema, Name: name}
+
+	var tableType string
+	var definition sql.NullString
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`
+		select type, sql from %s.sqlite_master where name = ? and type in ('table', 'view')`,
+		s.QuoteIdent(schema)), name).Scan(&tableType, &definition)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, tableName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	table.IsView = tableType == "view"
+
+	table.Columns, table.PrimaryKey, err = s.columns(ctx, db, schema, name)
+	if err != nil {
+		return nil, err
+	}
+
+	table.ForeignKeys, err = s.foreignKeys(ctx, db, schema, name)
+	if err != nil {
+		return nil, err
+	}
+
+	setCheckChoices(table, checkClauses(definition.String))
+
+	return table, nil
+}
+
+// checkClauses returns the check constraints of a create table statement, sqlite has no catalog of them.
+func checkClauses(definition string) []string {
+	out := make([]string, 0)
+	bare := unquoted(strings.ToLower(definition))
+
+	for i := 0; ; {
+		k := strings.Index(bare[i:], "check")
+		if k < 0 {
+			break
+		}
+		i += k + len("check")
+
+		start := i
+		for start < len(bare) && (bare[start] == ' ' || bare[start] == '\t' || bare[start] == '\n' || bare[start] == '\r') {
+			start++
+		}
+		if start >= len(bare) || bare[start] != '(' {
+			continue
+		}
+
+		depth := 0
+		for end := start; end < len(bare); end++ {
+			switch bare[end] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				out = append(out, definition[start+1:end])
+				i = end
+				break
+			}
+		}
+	}
+
+	return out
+}
+
+// columns returns the columns and the primary key of a table.
+func (s sqliteDialect) columns(ctx context.Context, db *sql.DB, schema, tableName string) ([]Column, []string, error) {
+	rows, err := db.QueryContext(ctx, `
+		select name, type, "notnull", dflt_value, pk
+		from pragma_table_info(?, ?)
+		order by cid`,
+		tableName, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]Column, 0)
+	keys := make(map[int]string)
+
+	for rows.Next() {
+		var column Column
+		var notNull bool
+		var def sql.NullString
+		var key int
+
+		if err := rows.Scan(&column.Name, &column.DatabaseType, &notNull, &def, &key); err != nil {
+			return nil, nil, err
+		}
+
+		column.DatabaseType = strings.ToLower(column.DatabaseType)
+		column.Type = s.FieldTypeToGo(column.DatabaseType)
+		column.Nullable = !notNull && key == 0
+		column.Default = def.String
+		column.HasDefault = def.Valid
+		column.IsPrimary = key > 0
+		if column.Type == "string" {
+			column.MaxLength = typeLength(column.DatabaseType)
+		}
+
+		if key > 0 {
+			keys[key] = column.Name
+		}
+
+		columns = append(columns, column)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	primaryKey := make([]string, 0, len(keys))
+	for i := 1; i <= len(keys); i++ {
+		primaryKey = append(primaryKey, keys[i])
+	}
+
+	// a single integer primary key is an alias of the rowid, filled by sqlite when omitted.
+	if len(primaryKey) == 1 {
+		for i, column := range columns {
+			if column.Name == primaryKey[0] && column.DatabaseType == "integer" {
+				columns[i].AutoGenerated = true
+				columns[i].HasDefault = true
+			}
+		}
+	}
+
+	return columns, primaryKey, nil
+}
+
+// foreignKeys returns the foreign keys of a table. sqlite does not report constraint names,
+// keys are named by their position. a key referencing no column references the primary key.
+func (s sqliteDialect) foreignKeys(ctx context.Context, db *sql.DB, schema, tableName string) ([]ForeignKey, error) {
+	rows, err := db.QueryContext(ctx, `
+		select id, "table", "from", "to"
+		from pragma_foreign_key_list(?, ?)
+		order by id, seq`,
+		tableName, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]foreignKeyRow, 0)
+	for rows.Next() {
+		var id int
+		var refColumn sql.NullString
+		var key foreignKeyRow
+
+		if err := rows.Scan(&id, &key.refTable, &key.column, &refColumn); err != nil {
+			return nil, err
+		}
+
+		key.name = strconv.Itoa(id)
+		key.refColumn = refColumn.String
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	out := groupForeignKeys(keys)
+
+	for i, key := range out {
+		if key.RefColumns[0] == "" {
+			_, primaryKey, err := s.columns(ctx, db, schema, key.RefTable)
+			if err != nil {
+				return nil, err
+			}
+			out[i].RefColumns = primaryKey
+		}
+
+		if schema != "main" {
+			out[i].RefTable = schema + "." + key.RefTable
+		}
+	}
+
+	return out, nil
+}
+
+// typeLength returns the length of a declared type like varchar(255), or zero.
+func typeLength(fieldType string) int {
+	start, end := strings.Index(fieldType, "("), strings.Index(fieldType, ")")
+	if start < 0 || end <= start {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSpace(fieldType[start+1 : end]))
+	return n
+}
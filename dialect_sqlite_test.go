@@ -0,0 +1,154 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newTestDB returns a sqlite database in a temporary directory, once the statements are run.
+func newTestDB(t *testing.T, stmts ...string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	return db
+}
+
+func TestSQLiteDataSourceName(t *testing.T) {
+	for uri, want := range map[string]string{
+		"sqlite:///tmp/app.db": "/tmp/app.db",
+		"sqlite://app.db":      "app.db",
+		"SQLite3://app.db":     "app.db",
+		"file:app.db":          "file:app.db",
+	} {
+		if got, _ := (sqliteDialect{}).DataSourceName(uri); got != want {
+			t.Errorf("%s: data source name = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestSQLiteFieldTypeToGo(t *testing.T) {
+	for fieldType, want := range map[string]string{
+		"INTEGER":       "int",
+		"bigint":        "int",
+		"varchar(100)":  "string",
+		"TEXT":          "string",
+		"clob":          "string",
+		"boolean":       "bool",
+		"date":          "time.Time",
+		"datetime":      "time.Time",
+		"timestamp":     "time.Time",
+		"real":          "float64",
+		"numeric(10,2)": "float64",
+		"blob":          "any",
+		"":              "any",
+	} {
+		if got := (sqliteDialect{}).FieldTypeToGo(fieldType); got != want {
+			t.Errorf("%q: go type = %s, want %s", fieldType, got, want)
+		}
+	}
+}
+
+func TestSQLiteTable(t *testing.T) {
+	pool := newTestDB(t,
+		"create table teams (id integer primary key, name text not null)",
+		`create table users (
+			id integer primary key,
+			team_id integer references teams,
+			name varchar(50) not null,
+			email text,
+			active boolean not null default 1,
+			role text check (role in ('admin', 'editor'))
+		)`,
+		"create table memberships (user_id integer, team_id integer, primary key (user_id, team_id))",
+		"create view active_users as select id, name from users where active",
+	)
+	ctx := context.Background()
+	d := sqliteDialect{}
+
+	names, err := d.Tables(ctx, pool, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"active_users", "memberships", "teams", "users"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tables = %v, want %v", names, want)
+	}
+
+	table, err := d.Table(ctx, pool, "users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.IsView || !reflect.DeepEqual(table.PrimaryKey, []string{"id"}) {
+		t.Errorf("users: view = %v, primary key = %v", table.IsView, table.PrimaryKey)
+	}
+	if want := []string{"id", "team_id", "name", "email", "active", "role"}; !reflect.DeepEqual(table.ColumnNames(), want) {
+		t.Errorf("users columns = %v, want %v", table.ColumnNames(), want)
+	}
+
+	id, _ := table.Column("id")
+	if id.Type != "int" || !id.IsPrimary || !id.AutoGenerated || id.Nullable {
+		t.Errorf("id = %+v, want an auto generated int primary key", id)
+	}
+
+	name, _ := table.Column("name")
+	if name.Type != "string" || name.MaxLength != 50 || name.Nullable {
+		t.Errorf("name = %+v, want a not null string of 50", name)
+	}
+
+	email, _ := table.Column("email")
+	if !email.Nullable || email.HasDefault {
+		t.Errorf("email = %+v, want a nullable column without default", email)
+	}
+
+	active, _ := table.Column("active")
+	if active.Type != "bool" || !active.HasDefault || active.Default != "1" {
+		t.Errorf("active = %+v, want a bool defaulting to 1", active)
+	}
+
+	role, _ := table.Column("role")
+	if !reflect.DeepEqual(role.EnumValues, []string{"admin", "editor"}) {
+		t.Errorf("role enum values = %v, want the check values", role.EnumValues)
+	}
+
+	want := []ForeignKey{{Name: "0", Columns: []string{"team_id"}, RefTable: "teams", RefColumns: []string{"id"}}}
+	if !reflect.DeepEqual(table.ForeignKeys, want) {
+		t.Errorf("foreign keys = %+v, want %+v", table.ForeignKeys, want)
+	}
+
+	memberships, err := d.Table(ctx, pool, "memberships")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(memberships.PrimaryKey, []string{"user_id", "team_id"}) {
+		t.Errorf("memberships primary key = %v", memberships.PrimaryKey)
+	}
+	if column, _ := memberships.Column("user_id"); column.AutoGenerated {
+		t.Error("a column of a composite primary key is not auto generated")
+	}
+
+	view, err := d.Table(ctx, pool, "active_users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !view.IsView {
+		t.Error("active_users is not a view")
+	}
+
+	if _, err := d.Table(ctx, pool, "missing"); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("missing table error = %v, want ErrTableNotFound", err)
+	}
+}
@@ -0,0 +1,108 @@
+package crud
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDialectForURI(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"postgres://user@localhost/app", "postgres"},
+		{"postgresql://user@localhost/app", "postgres"},
+		{"host=localhost dbname=app", "postgres"},
+		{"mysql://root@localhost/app", "mysql"},
+		{"mariadb://root@localhost/app", "mysql"},
+		{"sqlite:///tmp/app.db", "sqlite"},
+		{"file:app.db?cache=shared", "sqlite"},
+	}
+
+	for _, tt := range tests {
+		d, err := dialectForURI(tt.uri)
+		if err != nil {
+			t.Errorf("%s: %v", tt.uri, err)
+			continue
+		}
+		if d.Name() != tt.want {
+			t.Errorf("%s: dialect = %s, want %s", tt.uri, d.Name(), tt.want)
+		}
+	}
+
+	if _, err := dialectForURI("oracle://localhost/app"); err == nil {
+		t.Error("oracle uri: want an error")
+	}
+}
+
+func TestDialectPlaceholders(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		want    [3]string
+	}{
+		{postgresDialect{}, [3]string{"$1", "$2", "$3"}},
+		{mysqlDialect{}, [3]string{"?", "?", "?"}},
+		{sqliteDialect{}, [3]string{"?", "?", "?"}},
+	}
+
+	for _, tt := range tests {
+		got := [3]string{tt.dialect.Placeholder(1), tt.dialect.Placeholder(2), tt.dialect.Placeholder(3)}
+		if got != tt.want {
+			t.Errorf("%s: placeholders = %v, want %v", tt.dialect.Name(), got, tt.want)
+		}
+	}
+}
+
+func TestDialectQuoteIdent(t *testing.T) {
+	tests := []struct {
+		dialect Dialect
+		name    string
+		want    string
+	}{
+		{postgresDialect{}, "users", `"users"`},
+		{postgresDialect{}, "public.users", `"public"."users"`},
+		{postgresDialect{}, `we"ird`, `"we""ird"`},
+		{sqliteDialect{}, "main.users", `"main"."users"`},
+		{mysqlDialect{}, "users", "`users`"},
+		{mysqlDialect{}, "app.users", "`app`.`users`"},
+		{mysqlDialect{}, "we`ird", "`we``ird`"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.dialect.QuoteIdent(tt.name); got != tt.want {
+			t.Errorf("%s: quote %s = %s, want %s", tt.dialect.Name(), tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDialectLimitOffset(t *testing.T) {
+	for _, d := range []Dialect{postgresDialect{}, mysqlDialect{}, sqliteDialect{}} {
+		if got := d.LimitOffset(20, 40); got != "limit 20 offset 40" {
+			t.Errorf("%s: limit clause = %s", d.Name(), got)
+		}
+	}
+}
+
+func TestCheckChoices(t *testing.T) {
+	tests := []struct {
+		clause string
+		column string
+		values []string
+	}{
+		{"CHECK (status in ('draft', 'published'))", "status", []string{"draft", "published"}},
+		{"CHECK ((status = ANY (ARRAY['draft'::text, 'it''s'::text])))", "status", []string{"draft", "it's"}},
+		{"(`notes`.`status` in (_utf8mb4'a',_utf8mb4'b'))", "status", []string{"a", "b"}},
+		{"stars in (1, 2, 3)", "stars", []string{"1", "2", "3"}},
+		{"status in ('a, b', 'c)')", "status", []string{"a, b", "c)"}},
+		{"status in ('a', 'b') and stars > 0", "", nil},
+		{"stars > 0", "", nil},
+		{"status in (lower('A'))", "", nil},
+	}
+
+	for _, tt := range tests {
+		column, values, ok := checkChoices(tt.clause)
+		if ok != (tt.column != "") || column != tt.column || !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("%s: choices = %s %v %v, want %s %v", tt.clause, column, values, ok, tt.column, tt.values)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// prepareEntity checks the entity against the table schema and fills its defaults.
+func (a *Admin) prepareEntity(ctx context.Context, entity Entity) (Entity, error) {
+	table, err := a.db.Table(ctx, entity.TableName)
+	if errors.Is(err, ErrTableNotFound) {
+		return entity, fmt.Errorf("entity %q: table does not exist", entity.TableName)
+	}
+	if err != nil {
+		return entity, fmt.Errorf("entity %q: %w", entity.TableName, err)
+	}
+
+	if entity.PrimaryKey == "" {
+		switch {
+		case len(table.PrimaryKey) == 1:
+			entity.PrimaryKey = table.PrimaryKey[0]
+		case len(table.PrimaryKey) > 1:
+			return entity, fmt.Errorf("entity %q: composite primary key (%s) is not supported, set PrimaryKey to a unique column",
+				entity.TableName, strings.Join(table.PrimaryKey, ", "))
+		default:
+			if _, ok := table.Column("id"); !ok {
+				return entity, fmt.Errorf("entity %q: table has no primary key, set PrimaryKey to a unique column", entity.TableName)
+			}
+			entity.PrimaryKey = "id"
+		}
+	}
+
+	if _, ok := table.Column(entity.PrimaryKey); !ok {
+		return entity, fmt.Errorf("entity %q: primary key column %q does not exist", entity.TableName, entity.PrimaryKey)
+	}
+
+	filterColumns := make([]string, 0, len(entity.Filters))
+	for _, filter := range entity.Filters {
+		filterColumns = append(filterColumns, filter.Column)
+	}
+
+	widgetColumns := make([]string, 0, len(entity.Widgets))
+	for column := range entity.Widgets {
+		widgetColumns = append(widgetColumns, column)
+	}
+
+	choiceColumns := make([]string, 0, len(entity.Choices))
+	for column := range entity.Choices {
+		choiceColumns = append(choiceColumns, column)
+	}
+
+	columns := []struct {
+		field string
+		names []string
+	}{
+		{"SelectColumns", entity.SelectColumns},
+		{"EditColumns", entity.EditColumns},
+		{"NewColumns", entity.NewColumns},
+		{"SortableColumns", entity.SortableColumns},
+		{"SearchColumns", entity.SearchColumns},
+		{"Filters", filterColumns},
+		{"Widgets", widgetColumns},
+		{"Choices", choiceColumns},
+		{"DefaultOrder", strings.Fields(entity.DefaultOrder)},
+	}
+
+	for _, c := range columns {
+		for i, name := range c.names {
+			// the default order has its direction after the column.
+			if name == "*" || (c.field == "DefaultOrder" && i > 0) {
+				continue
+			}
+			if _, ok := table.Column(name); !ok {
+				return entity, fmt.Errorf("entity %q: %s column %q does not exist", entity.TableName, c.field, name)
+			}
+		}
+	}
+
+	if entity.TitlePlural == "" {
+		entity.TitlePlural = title(replace("_", " ", table.Name))
+	}
+
+	if entity.TitleSingular == "" {
+		entity.TitleSingular = singular(entity.TitlePlural)
+	}
+
+	return entity, nil
+}
+
+// singular returns a naive singular form of an english plural word.
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	default:
+		return word
+	}
+}
+
+// maxDiscoveredSelectColumns is the number of columns listed by default for a discovered entity.
+const maxDiscoveredSelectColumns = 8
+
+// discovery represents an auto discovered schema.
+type discovery struct {
+	schema  string
+	include []string
+	exclude []string
+}
+
+// discoverEntities adds an entity for each table and view of the discovered schemas that is not
+// registered yet. tables without a usable primary key are skipped.
+func (a *Admin) discoverEntities(ctx context.Context) error {
+	order := 0
+	for _, entity := range a.Entities {
+		if entity.Order > order {
+			order = entity.Order
+		}
+	}
+
+	for _, d := range a.discoveries {
+		names, err := a.db.Tables(ctx, d.schema)
+		if err != nil {
+			return fmt.Errorf("discover schema %q: %w", d.schema, err)
+		}
+
+		for _, name := range names {
+			if _, ok := a.Entities[name]; ok {
+				continue
+			}
+
+			// the admin users and sessions tables hold password and token hashes, they are never entities.
+			if a.authTable(name) {
+				continue
+			}
+
+			_, bare := splitTableName(name)
+			if !d.matches(bare) {
+				continue
+			}
+
+			table, err := a.db.Table(ctx, name)
+			if err != nil {
+				return fmt.Errorf("discover table %q: %w", name, err)
+			}
+
+			entity, ok := discoveredEntity(name, table)
+			if !ok {
+				continue
+			}
+
+			order++
+			entity.Order = order
+			a.Entities[name] = entity
+		}
+	}
+
+	return nil
+}
+
+// matches reports whether the table name matches an include pattern and no exclude pattern.
+func (d discovery) matches(name string) bool {
+	for _, pattern := range d.exclude {
+		if ok, _ := path.Match(pattern, name); ok {
+			return false
+		}
+	}
+
+	if len(d.include) == 0 {
+		return true
+	}
+
+	for _, pattern := range d.include {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// discoveredEntity builds the entity of a discovered table. the primary key, select and edit columns
+// are inferred from the schema; columns filled by the database, like serials and timestamps with a
+// default, are left out of the forms. views are read-only.
+func discoveredEntity(name string, table *Table) (Entity, bool) {
+	entity := Entity{
+		TableName:   name,
+		Description: table.Comment,
+		FavIcon:     "fa-table",
+	}
+
+	if table.IsView {
+		entity.FavIcon = "fa-eye"
+		entity.ReadOnly = true
+	}
+
+	switch {
+	case len(table.PrimaryKey) == 1:
+		entity.PrimaryKey = table.PrimaryKey[0]
+	case len(table.PrimaryKey) == 0:
+		if _, ok := table.Column("id"); !ok {
+			return entity, false
+		}
+		entity.PrimaryKey = "id"
+	default:
+		return entity, false
+	}
+
+	for _, column := range table.Columns {
+		if column.Type != "any" && len(entity.SelectColumns) < maxDiscoveredSelectColumns {
+			entity.SelectColumns = append(entity.SelectColumns, column.Name)
+		}
+
+		if column.Name == entity.PrimaryKey || column.AutoGenerated || (column.Type == "time.Time" && column.HasDefault) {
+			continue
+		}
+		entity.EditColumns = append(entity.EditColumns, column.Name)
+	}
+
+	if !slices.Contains(entity.SelectColumns, entity.PrimaryKey) {
+		entity.SelectColumns = append([]string{entity.PrimaryKey}, entity.SelectColumns...)
+	}
+
+	return entity, true
+}
@@ -0,0 +1,86 @@
+package crud
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// exportEntity writes the rows of the list of an entity as csv, with the filters and the order of the list and
+// without pagination. exporting needs the read and export permissions. the values of the columns with a
+// password widget are left out.
+func (a *Admin) exportEntity(w http.ResponseWriter, r *http.Request) {
+	entityName := chi.URLParam(r, "entity")
+	entity, ok := a.Entities[entityName]
+	if !ok {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+
+	if !a.can(r, entityName, "read") {
+		w.WriteHeader(http.StatusForbidden)
+		a.renderNotAuthorised(w, r)
+		return
+	}
+
+	table, err := a.db.Table(r.Context(), entity.TableName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sortColumn, sortDesc := entity.getOrder(r.URL.Query(), entity.getSortableColumns(table))
+	filters, _ := entity.getFilters(r.URL.Query(), table)
+
+	scope, err := a.scope(r.Context(), entity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, columns, _, err := a.db.GetTableColumenRows(r.Context(), entity.TableName, entity.PrimaryKey, entity.getSelectColumns(), ListOptions{
+		SortColumn: sortColumn,
+		SortDesc:   sortDesc,
+		Filters:    append(scope, filters...),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", entity.TableName+".csv"))
+
+	out := csv.NewWriter(w)
+	_ = out.Write(columns)
+
+	for _, row := range rows {
+		record := make([]string, 0, len(row.Columns))
+		for _, column := range row.Columns {
+			if _, ok := entity.getWidget(column).(PasswordWidget); ok {
+				record = append(record, "")
+				continue
+			}
+			record = append(record, column.Text())
+		}
+		_ = out.Write(record)
+	}
+
+	out.Flush()
+}
+
+// exportURL returns the export url of an entity list, keeping the filters and the order of the query.
+func (a *Admin) exportURL(entity Entity, query url.Values) string {
+	query.Del("page")
+	query.Del("page_size")
+
+	u := path.Join(a.BaseURL, "/entity/", entity.TableName, "export")
+	if len(query) == 0 {
+		return u
+	}
+	return u + "?" + query.Encode()
+}
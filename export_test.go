@@ -0,0 +1,85 @@
+package crud
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	db := newTestDB(t,
+		"create table accounts (id integer primary key, org_id integer not null, name text not null, secret text)",
+		"insert into accounts values (1, 1, 'alpha', 'hash-1'), (2, 1, 'beta, inc', 'hash-2'), (3, 2, 'other', 'hash-3')",
+	)
+
+	checker := func(r *http.Request, userID, entityName, action string) bool {
+		return userID != "reader" || action == "read"
+	}
+	scope := func(r *http.Request, userID string) ([]FilterValue, error) {
+		return []FilterValue{{Column: "org_id", Kind: FilterExact, Values: []string{"1"}}}, nil
+	}
+
+	h := newTestAdmin(t, db,
+		WithEntity(Entity{
+			TableName: "accounts",
+			Scope:     scope,
+			Filters:   []Filter{{Column: "name", Kind: FilterContains}},
+			Widgets:   map[string]Widget{"secret": PasswordWidget{}},
+		}),
+		WithUserIdentifier(headerUser),
+		WithPermissionChecker(checker)).GetMux()
+
+	tests := []struct {
+		name  string
+		query string
+		want  [][]string
+	}{
+		{"scope", "?sort=name&dir=desc", [][]string{{"id", "org_id", "name", "secret"}, {"2", "1", "beta, inc", ""}, {"1", "1", "alpha", ""}}},
+		{"filters", "?f_name=alp", [][]string{{"id", "org_id", "name", "secret"}, {"1", "1", "alpha", ""}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, testRequest(http.MethodGet, "/admin/entity/accounts/export"+tt.query, "editor", nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="accounts.csv"` {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+
+			records, err := csv.NewReader(w.Body).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != len(tt.want) {
+				t.Fatalf("records = %q, want %q", records, tt.want)
+			}
+			for i := range records {
+				if strings.Join(records[i], "|") != strings.Join(tt.want[i], "|") {
+					t.Errorf("record %d = %q, want %q", i, records[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("list button", func(t *testing.T) {
+		body := serve(h, testRequest(http.MethodGet, "/admin/entity/accounts?f_name=alp&page=2", "editor", nil)).Body.String()
+		if !strings.Contains(body, `href="/admin/entity/accounts/export?f_name=alp"`) {
+			t.Errorf("list does not link to the export")
+		}
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		w := serve(h, testRequest(http.MethodGet, "/admin/entity/accounts/export", "reader", nil))
+		if body := w.Body.String(); !strings.Contains(body, "Permission denied") || strings.Contains(body, "alpha") {
+			t.Errorf("export is not denied to the reader")
+		}
+
+		body := serve(h, testRequest(http.MethodGet, "/admin/entity/accounts", "reader", nil)).Body.String()
+		if strings.Contains(body, "/export") {
+			t.Errorf("list links to the export without the permission")
+		}
+	})
+}
@@ -0,0 +1,225 @@
+package crud
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FilterKind represents how a list filter matches a column.
+type FilterKind string
+
+const (
+	// FilterExact matches the rows where the column equals the value.
+	FilterExact FilterKind = "exact"
+	// FilterContains matches the rows where the column contains the value, ignoring case.
+	FilterContains FilterKind = "contains"
+	// FilterRange matches the rows where the column is between two values. either bound may be left empty.
+	FilterRange FilterKind = "range"
+	// FilterIn matches the rows where the column is one of the selected values.
+	FilterIn FilterKind = "in"
+	// FilterIsNull matches the rows where the column is, or is not, null.
+	FilterIsNull FilterKind = "isnull"
+)
+
+// Filter represents a list filter on a column.
+type Filter struct {
+	// Column represents the filtered column name.
+	Column string
+	// Kind represents how the column is matched. default is FilterExact.
+	Kind FilterKind
+	// Label represents the filter label. default is the column name.
+	Label string
+	// Options represents the values offered by a FilterIn filter. default is the column choices.
+	Options []string
+}
+
+// FilterValue represents an active filter of a list query.
+// exact, contains and isnull filters have one value, range filters have a from and a to value
+// where an empty bound is ignored, and in filters have one value per selected option.
+type FilterValue struct {
+	Column string
+	Kind   FilterKind
+	Values []string
+}
+
+// FilterField represents a filter input of the list template.
+type FilterField struct {
+	Filter
+
+	Param     string
+	InputType string
+	Value     string
+	From      string
+	To        string
+	Options   []FilterOption
+	Active    bool
+	// Error represents the error of a value that is not a value of the column type. the value is ignored.
+	Error string
+}
+
+// FilterOption represents an option of an in filter.
+type FilterOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// getFilters reads the entity filters from the query string. a filter on column "status" is read from
+// the f_status parameter, and a range filter from the f_status_from and f_status_to parameters. values that
+// are not values of the column type are left out, with an error on their field.
+func (e Entity) getFilters(query url.Values, table *Table) ([]FilterValue, []FilterField) {
+	values := make([]FilterValue, 0)
+	fields := make([]FilterField, 0, len(e.Filters))
+
+	for _, filter := range e.Filters {
+		if filter.Kind == "" {
+			filter.Kind = FilterExact
+		}
+		if filter.Label == "" {
+			filter.Label = filter.Column
+		}
+
+		field := FilterField{
+			Filter:    filter,
+			Param:     "f_" + filter.Column,
+			InputType: "text",
+		}
+		choices := make([]Choice, 0)
+		column, ok := table.Column(filter.Column)
+		if ok {
+			field.InputType = goTypeToHTMLType(column.Type)
+			choices = e.getChoices(column)
+			if filter.Kind == FilterIn && len(filter.Options) == 0 {
+				for _, choice := range choices {
+					filter.Options = append(filter.Options, choice.Value)
+				}
+			}
+		}
+
+		// valid reports whether a value is a value of the column type, so the database can compare it.
+		valid := func(value string) bool {
+			if !ok || value == "" {
+				return true
+			}
+
+			column.Nullable = false
+			if _, err := parseFormValue(column, value); err != nil {
+				field.Error = fmt.Sprintf("%s %s", strconv.Quote(value), err)
+				return false
+			}
+
+			return true
+		}
+
+		switch filter.Kind {
+		case FilterRange:
+			field.From = strings.TrimSpace(query.Get(field.Param + "_from"))
+			field.To = strings.TrimSpace(query.Get(field.Param + "_to"))
+			from, to := field.From, field.To
+			if !valid(from) {
+				from = ""
+			}
+			if !valid(to) {
+				to = ""
+			}
+			field.Active = from != "" || to != ""
+			if field.Active {
+				values = append(values, FilterValue{Column: filter.Column, Kind: filter.Kind, Values: []string{from, to}})
+			}
+		case FilterIn:
+			selected := make([]string, 0)
+			for _, value := range query[field.Param] {
+				if value != "" && slices.Contains(filter.Options, value) && valid(value) {
+					selected = append(selected, value)
+				}
+			}
+			for _, option := range filter.Options {
+				field.Options = append(field.Options, FilterOption{Value: option, Label: choiceLabel(choices, option), Selected: slices.Contains(selected, option)})
+			}
+			field.Active = len(selected) > 0
+			if field.Active {
+				values = append(values, FilterValue{Column: filter.Column, Kind: filter.Kind, Values: selected})
+			}
+		case FilterIsNull:
+			field.Value = query.Get(field.Param)
+			field.Active = field.Value == "true" || field.Value == "false"
+			if field.Active {
+				values = append(values, FilterValue{Column: filter.Column, Kind: filter.Kind, Values: []string{field.Value}})
+			}
+		case FilterContains:
+			field.Value = strings.TrimSpace(query.Get(field.Param))
+			field.Active = field.Value != ""
+			if field.Active {
+				values = append(values, FilterValue{Column: filter.Column, Kind: filter.Kind, Values: []string{field.Value}})
+			}
+		default:
+			field.Value = strings.TrimSpace(query.Get(field.Param))
+			field.Active = field.Value != "" && valid(field.Value)
+			if field.Active {
+				values = append(values, FilterValue{Column: filter.Column, Kind: filter.Kind, Values: []string{field.Value}})
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return values, fields
+}
+
+// filterClause returns the where clause, without the where keyword, of the given filters. the values are bound to q.
+func filterClause(q *query, filters []FilterValue) string {
+	conds := make([]string, 0, len(filters))
+
+	for _, filter := range filters {
+		column := q.ident(filter.Column)
+
+		switch filter.Kind {
+		case FilterContains:
+			conds = append(conds, q.dialect.ContainsExpr(column, q.arg("%"+likeEscaper.Replace(filter.Values[0])+"%")))
+		case FilterRange:
+			if filter.Values[0] != "" {
+				conds = append(conds, fmt.Sprintf("%s >= %s", column, q.arg(filter.Values[0])))
+			}
+			if filter.Values[1] != "" {
+				conds = append(conds, fmt.Sprintf("%s <= %s", column, q.arg(filter.Values[1])))
+			}
+		case FilterIn:
+			if len(filter.Values) == 0 {
+				conds = append(conds, "1 = 0")
+				continue
+			}
+
+			placeHolders := make([]string, 0, len(filter.Values))
+			for _, value := range filter.Values {
+				placeHolders = append(placeHolders, q.arg(value))
+			}
+			conds = append(conds, fmt.Sprintf("%s in (%s)", column, strings.Join(placeHolders, ",")))
+		case FilterIsNull:
+			if filter.Values[0] == "true" {
+				conds = append(conds, fmt.Sprintf("%s is null", column))
+			} else {
+				conds = append(conds, fmt.Sprintf("%s is not null", column))
+			}
+		default:
+			conds = append(conds, fmt.Sprintf("%s = %s", column, q.arg(filter.Values[0])))
+		}
+	}
+
+	return strings.Join(conds, " and ")
+}
+
+// andFilterClause returns the conditions of the filters prefixed with "and", to follow other conditions.
+// it is empty without conditions.
+func andFilterClause(q *query, filters []FilterValue) string {
+	if clause := filterClause(q, filters); clause != "" {
+		return " and " + clause
+	}
+
+	return ""
+}
+
+// likeEscaper escapes the like wildcards of a user provided value.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
@@ -0,0 +1,101 @@
+package crud
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestGetFilters(t *testing.T) {
+	entity := Entity{Filters: []Filter{
+		{Column: "title", Kind: FilterContains},
+		{Column: "stars", Kind: FilterRange},
+		{Column: "status", Kind: FilterIn, Options: []string{"draft", "published"}},
+		{Column: "deleted_at", Kind: FilterIsNull},
+		{Column: "author"},
+	}}
+
+	query, _ := url.ParseQuery("f_title=+go+&f_stars_to=5&f_status=published&f_status=bogus&f_deleted_at=maybe&f_author=")
+	table := &Table{Columns: []Column{{Name: "title", Type: "string"}, {Name: "stars", Type: "int"}}}
+	values, fields := entity.getFilters(query, table)
+
+	want := []FilterValue{
+		{Column: "title", Kind: FilterContains, Values: []string{"go"}},
+		{Column: "stars", Kind: FilterRange, Values: []string{"", "5"}},
+		{Column: "status", Kind: FilterIn, Values: []string{"published"}},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("filters = %+v, want %+v", values, want)
+	}
+
+	if len(fields) != len(entity.Filters) {
+		t.Fatalf("fields = %d, want one per filter", len(fields))
+	}
+	if fields[1].InputType != "number" {
+		t.Errorf("stars input type = %s, want number", fields[1].InputType)
+	}
+	if fields[4].Kind != FilterExact || fields[4].Label != "author" || fields[4].Active {
+		t.Errorf("author field = %+v, want an inactive exact filter labelled by its column", fields[4])
+	}
+	if options := fields[2].Options; len(options) != 2 || options[0].Selected || !options[1].Selected {
+		t.Errorf("status options = %+v, want published selected", options)
+	}
+}
+
+func TestFilterClause(t *testing.T) {
+	filters := []FilterValue{
+		{Column: "title", Kind: FilterContains, Values: []string{"50%_off"}},
+		{Column: "stars", Kind: FilterRange, Values: []string{"1", ""}},
+		{Column: "status", Kind: FilterIn, Values: []string{"a", "b"}},
+		{Column: "deleted_at", Kind: FilterIsNull, Values: []string{"true"}},
+		{Column: "author", Kind: FilterExact, Values: []string{"ann"}},
+	}
+
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{postgresDialect{}, `"title"::text ilike $1 and "stars" >= $2 and "status" in ($3,$4) and "deleted_at" is null and "author" = $5`},
+		{mysqlDialect{}, "lower(cast(`title` as char)) like lower(?) and `stars` >= ? and `status` in (?,?) and `deleted_at` is null and `author` = ?"},
+	}
+
+	for _, tt := range tests {
+		q := (&DB{Dialect: tt.dialect}).newQuery()
+		clause, args := filterClause(q, filters), q.args
+		if clause != tt.want {
+			t.Errorf("%s: clause = %s, want %s", tt.dialect.Name(), clause, tt.want)
+		}
+		if want := []any{`%50\%\_off%`, "1", "a", "b", "ann"}; !reflect.DeepEqual(args, want) {
+			t.Errorf("%s: args = %v, want %v", tt.dialect.Name(), args, want)
+		}
+	}
+}
+
+func TestGetFiltersInvalidValues(t *testing.T) {
+	entity := Entity{Filters: []Filter{
+		{Column: "id"},
+		{Column: "stars", Kind: FilterRange},
+		{Column: "title"},
+	}}
+	table := &Table{Columns: []Column{{Name: "id", Type: "int"}, {Name: "stars", Type: "int"}, {Name: "title", Type: "string"}}}
+
+	query, _ := url.ParseQuery("f_id=abc&f_stars_from=x&f_stars_to=4&f_title=abc")
+	values, fields := entity.getFilters(query, table)
+
+	want := []FilterValue{
+		{Column: "stars", Kind: FilterRange, Values: []string{"", "4"}},
+		{Column: "title", Kind: FilterExact, Values: []string{"abc"}},
+	}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("filters = %+v, want %+v", values, want)
+	}
+	if fields[0].Error == "" || fields[0].Active || fields[0].Value != "abc" {
+		t.Errorf("id field = %+v, want an inactive field with an error, keeping the value", fields[0])
+	}
+	if fields[1].Error == "" || fields[1].From != "x" {
+		t.Errorf("stars field = %+v, want an error on the from value", fields[1])
+	}
+	if fields[2].Error != "" {
+		t.Errorf("title error = %s, want none", fields[2].Error)
+	}
+}
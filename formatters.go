@@ -0,0 +1,120 @@
+package crud
+
+import (
+	"html/template"
+)
+
+// nullHTML is the html of a NULL value.
+const nullHTML template.HTML = `<span class="text-muted font-italic">NULL</span>`
+
+// formatValue returns the html of a column value of a row. the entity value formatter of the column is
+// used first, then the default formatter of the column, then a link to the referenced row of relation
+// columns, then a badge for choice columns, then the formatter of the column type.
+func (a *Admin) formatValue(entity Entity, column Column, row Row) template.HTML {
+	if formatter, ok := entity.ValueFormatters[column.Name]; ok && formatter != nil {
+		return formatter(column.Value, row)
+	}
+
+	if formatter, ok := a.DefaultFormatters[column.Name]; ok && formatter != nil {
+		return formatter(column.Value, row)
+	}
+
+	if column.Related != nil && column.Related.URL == "" {
+		return template.HTML(template.HTMLEscapeString(column.Related.Text))
+	}
+	if column.Related != nil {
+		return template.HTML(`<a href="` + template.HTMLEscapeString(column.Related.URL) + `">` +
+			template.HTMLEscapeString(column.Related.Text) + `</a>`)
+	}
+
+	if choices := entity.getChoices(column); len(choices) > 0 && !column.IsNull() {
+		return choiceBadge(choices, column)
+	}
+
+	return typeFormatter(column)
+}
+
+// badgeClasses represents the badge colors of choices, by choice position.
+var badgeClasses = []string{"primary", "success", "info", "warning", "danger", "secondary", "dark"}
+
+// choiceBadge returns a badge with the label of the choice of a column value. values that are not a choice,
+// stored before the choices changed, get a light badge with their text.
+func choiceBadge(choices []Choice, column Column) template.HTML {
+	text := column.Text()
+	class := "light"
+
+	for i, choice := range choices {
+		if choice.Value == text {
+			class = badgeClasses[i%len(badgeClasses)]
+			break
+		}
+	}
+
+	return template.HTML(`<span class="badge badge-` + class + `">` + template.HTMLEscapeString(choiceLabel(choices, text)) + `</span>`)
+}
+
+// formatName returns the html of a column name. the entity column name formatter is used first,
+// then the name is title cased. row is the shown row, empty for list headers.
+func (a *Admin) formatName(entity Entity, name string, row Row) template.HTML {
+	if formatter, ok := entity.ColumnNameFormatter[name]; ok && formatter != nil {
+		return formatter(name, row)
+	}
+
+	return template.HTML(template.HTMLEscapeString(title(replace("_", " ", name))))
+}
+
+// formatRow sets the label and the display html of the columns of a row.
+func (a *Admin) formatRow(entity Entity, row Row) Row {
+	columns := make([]Column, len(row.Columns))
+	for i, column := range row.Columns {
+		column.Label = a.formatName(entity, column.Name, row)
+		column.Display = a.formatValue(entity, column, row)
+		columns[i] = column
+	}
+
+	row.Columns = columns
+	return row
+}
+
+// formRow formats a row for a form and renders the input of each column with its widget.
+// the inputs of hidden widgets are returned apart, their columns have no label.
+func (a *Admin) formRow(entity Entity, row Row, errs FieldErrors) (Row, []template.HTML) {
+	row = a.formatRow(entity, row)
+
+	columns := make([]Column, 0, len(row.Columns))
+	hidden := make([]template.HTML, 0)
+
+	for _, column := range row.Columns {
+		_, invalid := errs[column.Name]
+		widget := entity.getWidget(column)
+		input := widget.Render(Field{Column: column, ID: "input-" + column.Name, Invalid: invalid})
+
+		if _, ok := widget.(HiddenWidget); ok {
+			hidden = append(hidden, input)
+			continue
+		}
+
+		column.Input = input
+		columns = append(columns, column)
+	}
+
+	row.Columns = columns
+	return row, hidden
+}
+
+// typeFormatter returns the html of a column value by its type: NULL is marked, booleans are shown as
+// icons and other values as their escaped text.
+func typeFormatter(column Column) template.HTML {
+	if column.IsNull() {
+		return nullHTML
+	}
+
+	if b, ok := column.Value.(bool); ok && column.Type == "bool" {
+		if b {
+			return `<i class="fas fa-check text-success" title="true"></i>`
+		}
+		return `<i class="fas fa-times text-danger" title="false"></i>`
+	}
+
+	return template.HTML(template.HTMLEscapeString(column.Text()))
+}
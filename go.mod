@@ -4,6 +4,9 @@ go 1.21.1
 
 require (
 	github.com/go-chi/chi/v5 v5.0.10
+	github.com/go-sql-driver/mysql v1.7.1
 	github.com/lib/pq v1.10.9
-	golang.org/x/text v0.4.0
+	github.com/mattn/go-sqlite3 v1.14.17
+	golang.org/x/crypto v0.31.0
+	golang.org/x/text v0.21.0
 )
@@ -0,0 +1,436 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// inlineNewIndex is the row index of the empty row copied by the add button, replaced by the next index.
+	inlineNewIndex = "__new__"
+	// inlineIDField is the field of an inline row holding the child primary key, empty for new rows.
+	inlineIDField = "_pk"
+	// inlineDeleteField is the field of an inline row set when the child row must be deleted.
+	inlineDeleteField = "_delete"
+)
+
+// Inline represents the rows of a child entity edited on the edit page of their parent, like the users
+// of an organization.
+type Inline struct {
+	// Entity represents the name of the child entity.
+	Entity string
+	// Column represents the child column referencing the parent primary key. default is the column of the
+	// single column foreign key of the child table referencing the parent table.
+	Column string
+	// Columns represents the child columns edited inline. default is the edit columns of the child entity.
+	// the referencing column is never edited.
+	Columns []string
+	// Title represents the inline title. default is the title plural of the child entity.
+	Title string
+}
+
+// inlineChange represents a write to a child row, made when the parent form is saved.
+type inlineChange struct {
+	entity Entity
+	// id represents the primary key of the child row, empty when the row is created.
+	id      string
+	delete  bool
+	columns []Column
+	// scope represents the scope of the child entity, which the written row must match.
+	scope []FilterValue
+}
+
+// prepareInlines checks the inlines of the entities and fills their defaults. it runs once every entity is prepared.
+func (a *Admin) prepareInlines(ctx context.Context) error {
+	for name, entity := range a.Entities {
+		inlines := make([]Inline, 0, len(entity.Inlines))
+
+		for _, inline := range entity.Inlines {
+			child, ok := a.Entities[inline.Entity]
+			if !ok {
+				return fmt.Errorf("entity %q: inline references unknown entity %q", entity.TableName, inline.Entity)
+			}
+
+			table, err := a.db.Table(ctx, child.TableName)
+			if err != nil {
+				return fmt.Errorf("entity %q: %w", child.TableName, err)
+			}
+
+			if inline.Column == "" {
+				for _, key := range table.ForeignKeys {
+					if len(key.Columns) != 1 || key.RefTable != entity.TableName {
+						continue
+					}
+					if inline.Column != "" {
+						return fmt.Errorf("entity %q: inline %q has several foreign keys to %q, set Column",
+							entity.TableName, inline.Entity, entity.TableName)
+					}
+					inline.Column = key.Columns[0]
+				}
+			}
+			if inline.Column == "" {
+				return fmt.Errorf("entity %q: inline %q has no foreign key to %q, set Column", entity.TableName, inline.Entity, entity.TableName)
+			}
+
+			for _, column := range append([]string{inline.Column}, inline.Columns...) {
+				if _, ok := table.Column(column); !ok && column != "*" {
+					return fmt.Errorf("entity %q: inline %q column %q does not exist", entity.TableName, inline.Entity, column)
+				}
+			}
+
+			if inline.Title == "" {
+				inline.Title = child.TitlePlural
+			}
+
+			inlines = append(inlines, inline)
+		}
+
+		entity.Inlines = inlines
+		a.Entities[name] = entity
+	}
+
+	return nil
+}
+
+// inlineColumns returns the child entity of an inline and the columns edited inline.
+func (a *Admin) inlineColumns(ctx context.Context, inline Inline) (Entity, []Column, error) {
+	child := a.Entities[inline.Entity]
+
+	table, err := a.db.Table(ctx, child.TableName)
+	if err != nil {
+		return child, nil, err
+	}
+
+	names := inline.Columns
+	if len(names) == 0 {
+		names = child.getEditColumns()
+	}
+
+	columns := slices.DeleteFunc(child.getFormColumns(table, names), func(column Column) bool {
+		return column.Name == inline.Column
+	})
+
+	return child, columns, nil
+}
+
+// childRows returns the child rows of an inline referencing the parent row, in primary key order. rows out
+// of the scope of the child entity are left out.
+func (a *Admin) childRows(ctx context.Context, inline Inline, child Entity, columns []Column, parentID string) ([]Row, error) {
+	names := []string{child.PrimaryKey}
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+
+	scope, err := a.scope(ctx, child)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, _, _, err := a.db.GetTableColumenRows(ctx, child.TableName, child.PrimaryKey, names, ListOptions{
+		SortColumn: child.PrimaryKey,
+		Filters:    append(scope, FilterValue{Column: inline.Column, Kind: FilterExact, Values: []string{parentID}}),
+	})
+
+	return rows, err
+}
+
+// inlinePrefix returns the prefix of the form fields of an inline. the fields of a row are named
+// prefix-index-column.
+func inlinePrefix(inline Inline) string {
+	return "inline-" + inline.Entity
+}
+
+// inlineForms returns the posted rows of an inline by index, with the prefix taken off the field names.
+func inlineForms(form url.Values, prefix string) (map[int]url.Values, []int) {
+	rows := make(map[int]url.Values)
+	indexes := make([]int, 0)
+
+	for key, values := range form {
+		rest, ok := strings.CutPrefix(key, prefix+"-")
+		if !ok {
+			continue
+		}
+
+		index, name, ok := strings.Cut(rest, "-")
+		if !ok {
+			continue
+		}
+
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 {
+			continue
+		}
+
+		if _, ok := rows[i]; !ok {
+			rows[i] = make(url.Values)
+			indexes = append(indexes, i)
+		}
+		rows[i][name] = values
+	}
+
+	sort.Ints(indexes)
+	return rows, indexes
+}
+
+// getInlines returns the inlines of the edit page of a parent row. the rows are read from the database,
+// or from the posted form when it is rendered again with its errors, keyed by field name. the inlines of the
+// entities the user may not read are left out, so their rows are not posted and stay unchanged.
+func (a *Admin) getInlines(r *http.Request, entity Entity, parentID string, form url.Values, errs FieldErrors) ([]InlineData, error) {
+	ctx := r.Context()
+	out := make([]InlineData, 0, len(entity.Inlines))
+
+	for _, inline := range entity.Inlines {
+		if !a.can(r, inline.Entity, "read") {
+			continue
+		}
+
+		child, columns, err := a.inlineColumns(ctx, inline)
+		if err != nil {
+			return nil, err
+		}
+
+		prefix := inlinePrefix(inline)
+		data := InlineData{
+			Title:         inline.Title,
+			TitleSingular: child.TitleSingular,
+			Prefix:        prefix,
+		}
+
+		for _, column := range columns {
+			data.Headers = append(data.Headers, a.formatName(child, column.Name, Row{}))
+		}
+
+		rows := make([]Row, 0)
+		ids := make([]string, 0)
+		indexes := make([]int, 0)
+		deleted := make([]bool, 0)
+
+		if form == nil {
+			stored, err := a.childRows(ctx, inline, child, columns, parentID)
+			if err != nil {
+				return nil, err
+			}
+
+			for i, row := range stored {
+				values := make(map[string]any, len(row.Columns))
+				for _, column := range row.Columns {
+					values[column.Name] = column.Value
+				}
+
+				rows = append(rows, inlineRowOf(columns, func(column Column) any { return values[column.Name] }))
+				ids = append(ids, valueText(row.PrimaryKeyValue))
+				indexes = append(indexes, i)
+				deleted = append(deleted, false)
+			}
+
+			if err := a.loadRelated(r, child, rows); err != nil {
+				return nil, err
+			}
+		} else {
+			posted, postedIndexes := inlineForms(form, prefix)
+			for _, i := range postedIndexes {
+				values := posted[i]
+				rows = append(rows, inlineRowOf(columns, func(column Column) any {
+					if v := values[column.Name]; len(v) > 0 {
+						return v[len(v)-1]
+					}
+					return nil
+				}))
+				ids = append(ids, values.Get(inlineIDField))
+				indexes = append(indexes, i)
+				deleted = append(deleted, values.Get(inlineDeleteField) == "true")
+			}
+
+			// rejected keys may not be valid values of the referenced columns, the related rows are then left out.
+			_ = a.loadRelated(r, child, rows)
+		}
+
+		for i, row := range rows {
+			rowPrefix := fmt.Sprintf("%s-%d", prefix, indexes[i])
+			data.Rows = append(data.Rows, a.inlineRow(child, rowPrefix, row, ids[i], deleted[i], errs))
+			if indexes[i] >= data.Next {
+				data.Next = indexes[i] + 1
+			}
+		}
+
+		data.Empty = a.inlineRow(child, prefix+"-"+inlineNewIndex, inlineRowOf(columns, func(Column) any { return nil }), "", false, nil)
+
+		out = append(out, data)
+	}
+
+	return out, nil
+}
+
+// inlineRowOf returns a row of the inline columns holding the given values.
+func inlineRowOf(columns []Column, value func(column Column) any) Row {
+	row := Row{Columns: make([]Column, 0, len(columns))}
+	for _, column := range columns {
+		column.Value = value(column)
+		row.Columns = append(row.Columns, column)
+	}
+
+	return row
+}
+
+// inlineRow renders the inputs of a child row, named after the row prefix.
+func (a *Admin) inlineRow(entity Entity, prefix string, row Row, id string, deleted bool, errs FieldErrors) InlineRow {
+	out := InlineRow{Prefix: prefix, ID: id, Deleted: deleted}
+	if id != "" {
+		out.URL = a.detailURL(entity, id) + "/edit"
+	}
+
+	for _, column := range row.Columns {
+		name := prefix + "-" + column.Name
+		_, invalid := errs[name]
+
+		// the widget is picked by the column name, then the field is named after the row.
+		widget := entity.getWidget(column)
+		field := Field{Column: column, ID: "input-" + name, Invalid: invalid}
+		field.Name = name
+
+		input := widget.Render(field)
+		if _, ok := widget.(HiddenWidget); ok {
+			out.Hidden = append(out.Hidden, input)
+			continue
+		}
+
+		out.Cells = append(out.Cells, InlineCell{Input: input, Error: errs[name]})
+	}
+
+	return out
+}
+
+// parseInlines converts the posted child rows of the inlines of a parent row to the writes made when the
+// parent is saved. errors are keyed by field name. rows of other parents are ignored.
+func (a *Admin) parseInlines(ctx context.Context, entity Entity, parentID string, form url.Values) ([]inlineChange, FieldErrors, error) {
+	changes := make([]inlineChange, 0)
+	errs := make(FieldErrors)
+
+	for _, inline := range entity.Inlines {
+		child, columns, err := a.inlineColumns(ctx, inline)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		table, err := a.db.Table(ctx, child.TableName)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		parentColumn, _ := table.Column(inline.Column)
+		parentValue, err := parseFormValue(parentColumn, parentID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("inline %q: parent key %q: %w", inline.Entity, parentID, err)
+		}
+		parentColumn.Value = parentValue
+
+		stored, err := a.childRows(ctx, inline, child, columns, parentID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		scope, err := a.scope(ctx, child)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		existing := make(map[string]Row, len(stored))
+		for _, row := range stored {
+			existing[valueText(row.PrimaryKeyValue)] = row
+		}
+
+		posted, indexes := inlineForms(form, inlinePrefix(inline))
+		for _, i := range indexes {
+			values := posted[i]
+			rowPrefix := fmt.Sprintf("%s-%d", inlinePrefix(inline), i)
+
+			id := values.Get(inlineIDField)
+			row, ok := existing[id]
+			if id != "" && !ok {
+				continue
+			}
+
+			if values.Get(inlineDeleteField) == "true" {
+				if id != "" {
+					changes = append(changes, inlineChange{entity: child, id: id, delete: true, scope: scope})
+				}
+				continue
+			}
+
+			parsed, rowErrs := child.parseForm(values, columns, id == "")
+			if id == "" {
+				parsed = append(parsed, parentColumn)
+			}
+			if len(rowErrs) == 0 {
+				parsed, rowErrs, err = scopeColumns(table, scope, parsed, id == "")
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			if len(rowErrs) == 0 {
+				rowErrs, err = a.checkRelations(ctx, child, parsed)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			for name, e := range rowErrs {
+				errs[rowPrefix+"-"+name] = e
+			}
+			if len(rowErrs) > 0 {
+				continue
+			}
+
+			if id != "" && unchangedRow(row, parsed) {
+				continue
+			}
+			changes = append(changes, inlineChange{entity: child, id: id, columns: parsed, scope: scope})
+		}
+	}
+
+	return changes, errs, nil
+}
+
+// unchangedRow reports whether the columns hold the values of the stored row, so writing them changes nothing.
+func unchangedRow(row Row, columns []Column) bool {
+	for _, column := range columns {
+		i := slices.IndexFunc(row.Columns, func(stored Column) bool { return stored.Name == column.Name })
+		if i < 0 || row.Columns[i].IsNull() != column.IsNull() || valueText(row.Columns[i].Value) != valueText(column.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// action returns the permission action of the write to a child row.
+func (c inlineChange) action() string {
+	switch {
+	case c.delete:
+		return "delete"
+	case c.id == "":
+		return "create"
+	default:
+		return "update"
+	}
+}
+
+// saveInline makes a write to a child row.
+func saveInline(ctx context.Context, db *DB, change inlineChange) error {
+	entity := change.entity
+
+	switch {
+	case change.delete:
+		return db.DeleteEntityByID(ctx, entity.TableName, entity.PrimaryKey, change.id, change.scope...)
+	case change.id == "":
+		return db.CreateEntity(ctx, entity.TableName, entity.PrimaryKey, change.columns)
+	default:
+		return db.UpdateEntity(ctx, entity.TableName, entity.PrimaryKey, change.id, change.columns, change.scope...)
+	}
+}
@@ -0,0 +1,35 @@
+package crud
+
+import (
+	"context"
+	"log"
+)
+
+// Mail represents a plain text mail.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer represents the sender of the mails of the built-in authentication, like the password reset links.
+type Mailer interface {
+	Send(ctx context.Context, mail Mail) error
+}
+
+// LogMailer represents a mailer that writes the mails to a logger instead of sending them, for local development.
+type LogMailer struct {
+	// Logger represents the logger of the mails. default is the standard logger.
+	Logger *log.Logger
+}
+
+// Send writes the mail to the logger.
+func (m LogMailer) Send(ctx context.Context, mail Mail) error {
+	logger := m.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	logger.Printf("mail to %s: %s\n%s", mail.To, mail.Subject, mail.Body)
+	return nil
+}
@@ -0,0 +1,399 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	// manyToManyPrefix is the prefix of the form fields of the many to many relations.
+	manyToManyPrefix = "m2m-"
+	// manyToManyPreview is the number of linked rows named in a list cell.
+	manyToManyPreview = 3
+)
+
+// ManyToMany represents the rows of another entity linked through a join table, like the permissions of a user.
+type ManyToMany struct {
+	// Name represents the name of the relation, used as its form field and list column. default is the linked entity name.
+	Name string
+	// Entity represents the name of the linked entity.
+	Entity string
+	// JoinTable represents the join table name.
+	JoinTable string
+	// LocalKey represents the join table column referencing the entity primary key. default is the column of the
+	// foreign key of the join table referencing the entity table.
+	LocalKey string
+	// RemoteKey represents the join table column referencing the linked entity primary key. default is the column
+	// of the foreign key of the join table referencing the linked entity table.
+	RemoteKey string
+	// DisplayColumn represents the column of the linked entity shown for a linked row. default is the display
+	// column of a relation to the linked entity.
+	DisplayColumn string
+	// Title represents the label of the relation. default is the title plural of the linked entity.
+	Title string
+}
+
+// join returns the join table of the relation.
+func (m ManyToMany) join() JoinTable {
+	return JoinTable{Name: m.JoinTable, LocalKey: m.LocalKey, RemoteKey: m.RemoteKey}
+}
+
+// linkChange represents the linked keys of a many to many relation, set when the entity form is saved.
+type linkChange struct {
+	relation ManyToMany
+	keys     []any
+	// adds and removes report whether links are inserted or deleted.
+	adds    bool
+	removes bool
+}
+
+// prepareManyToMany checks the many to many relations of the entities and fills their defaults.
+// it runs once every entity is prepared.
+func (a *Admin) prepareManyToMany(ctx context.Context) error {
+	for name, entity := range a.Entities {
+		relations := make([]ManyToMany, 0, len(entity.ManyToMany))
+
+		for _, m := range entity.ManyToMany {
+			target, ok := a.Entities[m.Entity]
+			if !ok {
+				return fmt.Errorf("entity %q: many to many references unknown entity %q", entity.TableName, m.Entity)
+			}
+
+			join, err := a.db.Table(ctx, m.JoinTable)
+			if err != nil {
+				return fmt.Errorf("entity %q: join table %q: %w", entity.TableName, m.JoinTable, err)
+			}
+
+			// a self referencing relation has two keys to the same table, they must be set.
+			if entity.TableName != target.TableName {
+				for _, key := range join.ForeignKeys {
+					if len(key.Columns) != 1 {
+						continue
+					}
+					if m.LocalKey == "" && key.RefTable == entity.TableName {
+						m.LocalKey = key.Columns[0]
+					}
+					if m.RemoteKey == "" && key.RefTable == target.TableName {
+						m.RemoteKey = key.Columns[0]
+					}
+				}
+			}
+
+			if m.LocalKey == "" || m.RemoteKey == "" {
+				return fmt.Errorf("entity %q: join table %q keys are not found, set LocalKey and RemoteKey", entity.TableName, m.JoinTable)
+			}
+
+			for _, column := range []string{m.LocalKey, m.RemoteKey} {
+				if _, ok := join.Column(column); !ok {
+					return fmt.Errorf("entity %q: join table %q column %q does not exist", entity.TableName, m.JoinTable, column)
+				}
+			}
+
+			targetTable, err := a.db.Table(ctx, target.TableName)
+			if err != nil {
+				return fmt.Errorf("entity %q: %w", target.TableName, err)
+			}
+
+			if m.DisplayColumn == "" {
+				m.DisplayColumn = displayColumn(target, targetTable)
+			}
+			if _, ok := targetTable.Column(m.DisplayColumn); !ok {
+				return fmt.Errorf("entity %q: many to many %q display column %q does not exist", entity.TableName, m.Entity, m.DisplayColumn)
+			}
+
+			if m.Name == "" {
+				m.Name = m.Entity
+			}
+			if m.Title == "" {
+				m.Title = target.TitlePlural
+			}
+
+			relations = append(relations, m)
+		}
+
+		entity.ManyToMany = relations
+		a.Entities[name] = entity
+	}
+
+	return nil
+}
+
+// getManyToMany returns the many to many fields of the edit page of a row. the linked rows are read from the
+// database, or from the posted form when it is rendered again with its errors, keyed by field name. the
+// relations to the entities the user may not read have no field, so their links are left unchanged.
+func (a *Admin) getManyToMany(r *http.Request, entity Entity, id string, form url.Values, errs FieldErrors) ([]ManyToManyField, error) {
+	ctx := r.Context()
+	out := make([]ManyToManyField, 0, len(entity.ManyToMany))
+
+	for _, m := range a.readableManyToMany(r, entity) {
+		target := a.Entities[m.Entity]
+		name := manyToManyPrefix + m.Name
+
+		scope, err := a.scope(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+
+		var linked []LookupResult
+		if values, ok := form[name]; ok {
+			keys := make([]any, 0, len(values))
+			for _, value := range values {
+				if value != "" {
+					keys = append(keys, value)
+				}
+			}
+
+			// rejected keys may not be valid keys of the linked entity, they are then shown as is.
+			texts, _ := a.db.GetDisplayValues(ctx, target.TableName, target.PrimaryKey, m.DisplayColumn, keys, scope...)
+			for _, key := range keys {
+				text, ok := texts[valueText(key)]
+				if !ok {
+					text = valueText(key)
+				}
+				linked = append(linked, LookupResult{ID: valueText(key), Text: text})
+			}
+		} else {
+			rows, err := a.db.GetLinkedRows(ctx, m.join(), target.TableName, target.PrimaryKey, m.DisplayColumn, []any{id}, scope...)
+			if err != nil {
+				return nil, fmt.Errorf("load %s: %w", m.Name, err)
+			}
+			linked = rows[id]
+		}
+
+		choices := make([]widgetChoice, 0, len(linked))
+		for _, row := range linked {
+			choices = append(choices, widgetChoice{Choice: Choice{Value: row.ID, Label: row.Text}, Selected: true})
+		}
+
+		_, invalid := errs[name]
+		field := Field{Column: Column{Name: name, Nullable: true}, ID: "input-" + name, Invalid: invalid}
+
+		out = append(out, ManyToManyField{
+			Name:  name,
+			Label: m.Title,
+			Input: renderWidget("widget-select", widgetData{
+				Field:    field,
+				Choices:  choices,
+				URL:      a.lookupURL(Relation{Entity: m.Entity, DisplayColumn: m.DisplayColumn}),
+				Multiple: true,
+			}),
+			Error: errs[name],
+		})
+	}
+
+	return out, nil
+}
+
+// parseManyToMany converts the posted keys of the many to many relations of an entity. relations whose field
+// is not posted are left unchanged, and keys out of the scope of the linked entity are rejected. errors are
+// keyed by field name.
+func (a *Admin) parseManyToMany(ctx context.Context, entity Entity, id string, form url.Values) ([]linkChange, FieldErrors, error) {
+	changes := make([]linkChange, 0)
+	errs := make(FieldErrors)
+
+	for _, m := range entity.ManyToMany {
+		name := manyToManyPrefix + m.Name
+		values, ok := form[name]
+		if !ok {
+			continue
+		}
+
+		target := a.Entities[m.Entity]
+		targetTable, err := a.db.Table(ctx, target.TableName)
+		if err != nil {
+			return nil, nil, err
+		}
+		targetKey, _ := targetTable.Column(target.PrimaryKey)
+
+		keys := make([]any, 0, len(values))
+		for _, value := range values {
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+
+			key, err := parseFormValue(targetKey, value)
+			if err != nil {
+				errs[name] = fmt.Sprintf("%s %s", strconv.Quote(value), err)
+				break
+			}
+			keys = append(keys, key)
+		}
+		if _, ok := errs[name]; ok {
+			continue
+		}
+
+		scope, err := a.scope(ctx, target)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		texts, err := a.db.GetDisplayValues(ctx, target.TableName, target.PrimaryKey, m.DisplayColumn, keys, scope...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range keys {
+			if _, ok := texts[valueText(key)]; !ok {
+				errs[name] = fmt.Sprintf("%s does not exist", strconv.Quote(valueText(key)))
+				break
+			}
+		}
+		if _, ok := errs[name]; ok {
+			continue
+		}
+
+		change, err := a.diffLinks(ctx, m, id, keys, target, scope)
+		if err != nil {
+			return nil, nil, err
+		}
+		if change.adds || change.removes {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, errs, nil
+}
+
+// diffLinks returns the change setting the linked keys of a row, with the links it inserts and deletes. the
+// links to rows out of the scope of the user are not shown on the form, so they are kept.
+func (a *Admin) diffLinks(ctx context.Context, m ManyToMany, id string, keys []any, target Entity, scope []FilterValue) (linkChange, error) {
+	change := linkChange{relation: m, keys: keys}
+
+	join, err := a.db.Table(ctx, m.JoinTable)
+	if err != nil {
+		return change, err
+	}
+
+	localKey, _ := join.Column(m.LocalKey)
+	local, err := parseFormValue(localKey, id)
+	if err != nil {
+		return change, fmt.Errorf("%s key %q: %w", m.Name, id, err)
+	}
+
+	current, err := a.db.GetLinks(ctx, m.join(), local)
+	if err != nil {
+		return change, err
+	}
+
+	if len(scope) > 0 && len(current) > 0 {
+		linked := make([]any, 0, len(current))
+		for _, key := range current {
+			linked = append(linked, key)
+		}
+
+		visible, err := a.db.GetDisplayValues(ctx, target.TableName, target.PrimaryKey, m.DisplayColumn, linked, scope...)
+		if err != nil {
+			return change, err
+		}
+
+		for text, key := range current {
+			if _, ok := visible[text]; !ok {
+				change.keys = append(change.keys, key)
+			}
+		}
+		keys = change.keys
+	}
+
+	wanted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		text := valueText(key)
+		wanted[text] = true
+		if _, ok := current[text]; !ok {
+			change.adds = true
+		}
+	}
+
+	for text := range current {
+		if !wanted[text] {
+			change.removes = true
+		}
+	}
+
+	return change, nil
+}
+
+// saveLinks sets the linked keys of a many to many relation of a row.
+func (a *Admin) saveLinks(ctx context.Context, db *DB, id string, change linkChange) error {
+	join, err := db.Table(ctx, change.relation.JoinTable)
+	if err != nil {
+		return err
+	}
+
+	localKey, _ := join.Column(change.relation.LocalKey)
+	local, err := parseFormValue(localKey, id)
+	if err != nil {
+		return fmt.Errorf("%s key %q: %w", change.relation.Name, id, err)
+	}
+
+	return db.SetLinks(ctx, change.relation.join(), local, change.keys)
+}
+
+// loadLinkedPreviews adds a column to the rows for each many to many relation, naming the first linked rows.
+func (a *Admin) loadLinkedPreviews(r *http.Request, entity Entity, rows []Row) error {
+	ctx := r.Context()
+	keys := make([]any, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.PrimaryKeyValue)
+	}
+
+	for _, m := range a.readableManyToMany(r, entity) {
+		target := a.Entities[m.Entity]
+		scope, err := a.scope(ctx, target)
+		if err != nil {
+			return err
+		}
+
+		linked, err := a.db.GetLinkedRows(ctx, m.join(), target.TableName, target.PrimaryKey, m.DisplayColumn, keys, scope...)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", m.Name, err)
+		}
+
+		for i, row := range rows {
+			rows[i].Columns = append(row.Columns, Column{
+				Name:    m.Name,
+				Label:   template.HTML(template.HTMLEscapeString(m.Title)),
+				Display: linkedPreview(linked[valueText(row.PrimaryKeyValue)]),
+			})
+		}
+	}
+
+	return nil
+}
+
+// readableManyToMany returns the many to many relations of an entity to the entities the user may read.
+func (a *Admin) readableManyToMany(r *http.Request, entity Entity) []ManyToMany {
+	out := make([]ManyToMany, 0, len(entity.ManyToMany))
+	for _, m := range entity.ManyToMany {
+		if a.can(r, m.Entity, "read") {
+			out = append(out, m)
+		}
+	}
+
+	return out
+}
+
+// linkedPreview returns the html of the first linked rows and the number of the others.
+func linkedPreview(linked []LookupResult) template.HTML {
+	if len(linked) == 0 {
+		return `<span class="text-muted">None</span>`
+	}
+
+	names := make([]string, 0, manyToManyPreview)
+	for i, row := range linked {
+		if i == manyToManyPreview {
+			break
+		}
+		names = append(names, template.HTMLEscapeString(row.Text))
+	}
+
+	out := strings.Join(names, ", ")
+	if more := len(linked) - len(names); more > 0 {
+		out += fmt.Sprintf(` <span class="badge badge-light">+%d</span>`, more)
+	}
+
+	return template.HTML(out)
+}
@@ -1,5 +1,7 @@
 package crud
 
+import "html/template"
+
 // Menu represents a menu item.
 type Menu struct {
 	Order     int
@@ -7,6 +9,8 @@ type Menu struct {
 	Title     string
 	URL       string
 	FavIcon   string
+	// CanCreate reports whether the user may create rows of the entity.
+	CanCreate bool
 }
 
 // EditData represents the data needed to render the edit template.
@@ -18,22 +22,119 @@ type EditData struct {
 	EntityID    string
 
 	IsEdit bool
+	// Errors represents the errors of the invalid fields of a submitted form.
+	Errors FieldErrors
+	// Hidden represents the inputs of the columns with a hidden widget.
+	Hidden []template.HTML
+	// Inlines represents the child rows edited with the row.
+	Inlines []InlineData
+	// ManyToMany represents the inputs of the rows linked through join tables.
+	ManyToMany []ManyToManyField
 
 	BaseContextData
 }
 
+// DetailData represents the data needed to render the detail template.
+type DetailData struct {
+	Title       string
+	Description string
+	Row         Row
+	EntityName  string
+	EntityID    string
+	// Related represents the rows linked to the row through join tables and the child rows of its inlines.
+	Related []RelatedGroup
+
+	CanEdit   bool
+	CanDelete bool
+
+	BaseContextData
+}
+
+// RelatedGroup represents the rows of an entity linked to the row of the detail template.
+type RelatedGroup struct {
+	Title string
+	Rows  []RelatedRow
+	// Total represents the number of linked rows, Rows may hold only the first ones.
+	Total int
+}
+
+// More returns the number of linked rows left out of Rows.
+func (g RelatedGroup) More() int {
+	return g.Total - len(g.Rows)
+}
+
+// ManyToManyField represents the input of a many to many relation of the edit template.
+type ManyToManyField struct {
+	Name  string
+	Label string
+	Input template.HTML
+	Error string
+}
+
+// InlineData represents an inline of the edit template.
+type InlineData struct {
+	Title         string
+	TitleSingular string
+	// Prefix represents the prefix of the form fields of the inline.
+	Prefix  string
+	Headers []template.HTML
+	Rows    []InlineRow
+	// Empty represents the row copied by the add button.
+	Empty InlineRow
+	// Next represents the index of the next added row.
+	Next int
+}
+
+// InlineRow represents a child row of an inline.
+type InlineRow struct {
+	Prefix string
+	// ID represents the primary key of the child row, empty for new rows.
+	ID string
+	// URL represents the edit page of the child row.
+	URL     string
+	Deleted bool
+	Cells   []InlineCell
+	Hidden  []template.HTML
+}
+
+// InlineCell represents the input of a column of a child row.
+type InlineCell struct {
+	Input template.HTML
+	Error string
+}
+
 // ListData represents the data needed to render the list template.
 type ListData struct {
 	Title       string
 	Description string
 	EntityName  string
 
-	Columns []string
-	Rows    []Row
+	Columns    []string
+	Headers    []ListHeader
+	Rows       []Row
+	Pagination Pagination
+	SortColumn string
+	SortDesc   bool
+	Filters    []FilterField
+	CanCreate  bool
+	CanEdit    bool
+	CanDelete  bool
+	// ExportURL is the csv export of the list with its filters and order, empty when the user may not export.
+	ExportURL string
 
 	BaseContextData
 }
 
+// ListHeader represents a column header of the list template.
+type ListHeader struct {
+	Name     string
+	Label    template.HTML
+	Sortable bool
+	Sorted   bool
+	SortDesc bool
+	SortURL  string
+}
+
 // SearchResult represents the search results.
 type SearchResult struct {
 	Title       string
@@ -41,7 +142,18 @@ type SearchResult struct {
 	Link        string
 }
 
+// SearchGroup represents the search results of an entity.
+type SearchGroup struct {
+	EntityName string
+	Title      string
+	URL        string
+	Count      int
+
+	Results []SearchResult
+}
+
 // SearchData represents the data needed to render the search template.
+// results of a custom search handler are in Results, results of the built-in search are grouped by entity in Groups.
 type SearchData struct {
 	Query       string
 	ResultCount int
@@ -49,6 +161,23 @@ type SearchData struct {
 	BaseContextData
 
 	Results []SearchResult
+	Groups  []SearchGroup
+}
+
+// AuthData represents the data needed to render the login, register and password templates.
+type AuthData struct {
+	Error   string
+	Message string
+	Name    string
+	Email   string
+	// Next represents the page opened after the login.
+	Next string
+	// Token represents the password reset token.
+	Token string
+	// AllowRegister reports whether the register page is open.
+	AllowRegister bool
+
+	BaseContextData
 }
 
 // BaseContextData represents the data needed to render the base template.
@@ -56,4 +185,10 @@ type BaseContextData struct {
 	ShowSearchBar bool
 	BaseURL       string
 	Menus         []Menu
+	// UserName represents the name of the signed in user of the built-in authentication.
+	UserName string
+	// CanLogout reports whether the user is signed in with the built-in authentication.
+	CanLogout bool
+	// CSRFToken represents the token posted by the forms.
+	CSRFToken string
 }
@@ -1,19 +1,78 @@
 package crud
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
 	"path"
+	"time"
 )
 
 // Option represents an admin option.
 type Option func(*Admin) error
 
 // WithDatabaseURI returns an admin option that sets the database uri.
+// the dialect is picked from the uri scheme: postgres:// or postgresql://, mysql:// or mariadb://, and sqlite:// or file:.
+// when the option is given more than once, the last uri and its dialect are used.
 func WithDatabaseURI(uri string) Option {
 	return func(a *Admin) error {
+		if _, err := dialectForURI(uri); err != nil {
+			return err
+		}
+
 		a.DatabaseURI = uri
-		a.databaseEngine = path.Ext(uri)
+		return nil
+	}
+}
+
+// WithDialect returns an admin option that sets the database dialect, overriding the one picked from the uri or the driver.
+func WithDialect(dialect Dialect) Option {
+	return func(a *Admin) error {
+		a.dialect = dialect
+		return nil
+	}
+}
+
+// WithDB returns an admin option that sets the database connection pool.
+// the pool is owned by the caller and will not be closed by Admin.Close.
+func WithDB(db *sql.DB) Option {
+	return func(a *Admin) error {
+		a.pool = db
+		return nil
+	}
+}
+
+// WithMaxOpenConns returns an admin option that sets the maximum number of open database connections.
+func WithMaxOpenConns(n int) Option {
+	return func(a *Admin) error {
+		a.MaxOpenConns = n
+		return nil
+	}
+}
+
+// WithMaxIdleConns returns an admin option that sets the maximum number of idle database connections.
+func WithMaxIdleConns(n int) Option {
+	return func(a *Admin) error {
+		a.MaxIdleConns = n
+		return nil
+	}
+}
+
+// WithConnMaxLifetime returns an admin option that sets the maximum amount of time a database connection may be reused.
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(a *Admin) error {
+		a.ConnMaxLifetime = d
+		return nil
+	}
+}
+
+// WithConnMaxIdleTime returns an admin option that sets the maximum amount of time a database connection may be idle.
+func WithConnMaxIdleTime(d time.Duration) Option {
+	return func(a *Admin) error {
+		a.ConnMaxIdleTime = d
 		return nil
 	}
 }
@@ -26,6 +85,16 @@ func WithBaseURL(baseURL string) Option {
 	}
 }
 
+// WithSecureCookies returns an admin option that sets whether the cookies of the admin are only sent over https.
+// default is true, as the admin may run behind a proxy terminating tls. set it to false to serve the admin over
+// plain http, other than on localhost.
+func WithSecureCookies(secure bool) Option {
+	return func(a *Admin) error {
+		a.insecureCookies = !secure
+		return nil
+	}
+}
+
 // WithDefaultFormatters returns an admin option that sets the default formatters.
 func WithDefaultFormatters(formatters map[string]Formatter) Option {
 	return func(a *Admin) error {
@@ -60,6 +129,26 @@ func WithEntities(entities []Entity) Option {
 	}
 }
 
+// WithAutoDiscover returns an admin option that registers an entity for each table and view of a schema.
+// include and exclude are path.Match patterns on the table names, an empty include matches every table.
+// tables registered with WithEntity or WithEntities are not replaced. if schema is empty the current schema is used.
+func WithAutoDiscover(schema string, include, exclude []string) Option {
+	return func(a *Admin) error {
+		for _, pattern := range append(append([]string{}, include...), exclude...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("auto discover pattern %q: %w", pattern, err)
+			}
+		}
+
+		a.discoveries = append(a.discoveries, discovery{
+			schema:  schema,
+			include: include,
+			exclude: exclude,
+		})
+		return nil
+	}
+}
+
 // WithTemplateFuncs returns an admin option that adds template funcs.
 func WithTemplateFuncs(funcs template.FuncMap) Option {
 	return func(a *Admin) error {
@@ -111,3 +200,63 @@ func WithSearchHandler(fn func(r *http.Request, q string) ([]SearchResult, error
 		return nil
 	}
 }
+
+// WithAuth returns an admin option that enables the built-in authentication, with the login, logout, register and
+// password reset pages. the users are read from the session cookie unless a user identifier is set.
+func WithAuth(auth Auth) Option {
+	return func(a *Admin) error {
+		if len(auth.Secret) < 32 {
+			return fmt.Errorf("auth secret must be at least 32 bytes, got %d", len(auth.Secret))
+		}
+
+		if auth.PublicURL == "" {
+			return errors.New("auth public url is required for the password reset links")
+		}
+		if u, err := url.Parse(auth.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("auth public url %q must be an http or https url with a host", auth.PublicURL)
+		}
+
+		// reset links are never dropped silently, logging them must be asked for with a LogMailer.
+		if auth.Mailer == nil {
+			return errors.New("auth mailer is required for the password reset links, use LogMailer to log them")
+		}
+
+		if auth.TableName == "" {
+			auth.TableName = "crud_users"
+		}
+		if auth.Sessions == nil {
+			auth.Sessions = CookieSessionStore{Secret: auth.Secret}
+		}
+		if auth.IdleTimeout <= 0 {
+			auth.IdleTimeout = 2 * time.Hour
+		}
+		if auth.AbsoluteTimeout <= 0 {
+			auth.AbsoluteTimeout = 24 * time.Hour
+		}
+		if auth.ResetTokenTTL <= 0 {
+			auth.ResetTokenTTL = time.Hour
+		}
+
+		a.auth = &auth
+		return nil
+	}
+}
+
+// WithRBAC returns an admin option that enables the built-in role based access control. the permissions are
+// checked with the roles of the users unless a permission checker is set.
+func WithRBAC(rbac RBAC) Option {
+	return func(a *Admin) error {
+		if rbac.RolesTable == "" {
+			rbac.RolesTable = "crud_roles"
+		}
+		if rbac.PermissionsTable == "" {
+			rbac.PermissionsTable = "crud_permissions"
+		}
+		if rbac.UserRolesTable == "" {
+			rbac.UserRolesTable = "crud_user_roles"
+		}
+
+		a.rbac = &rbac
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package crud
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWithDatabaseURI(t *testing.T) {
+	uri := "sqlite://" + filepath.Join(t.TempDir(), "test.db")
+
+	a, err := New(WithDatabaseURI("postgres://localhost/app"), WithDatabaseURI(uri))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	if a.DatabaseURI != uri || a.db.Dialect.Name() != "sqlite" {
+		t.Errorf("uri = %s with the %s dialect, want the last uri and its dialect", a.DatabaseURI, a.db.Dialect.Name())
+	}
+
+	if _, err := New(WithDatabaseURI("oracle://localhost/app")); err == nil {
+		t.Error("unknown scheme: want an error")
+	}
+}
+
+func TestWithAuth(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	tests := []struct {
+		name string
+		auth Auth
+		ok   bool
+	}{
+		{"valid", Auth{Secret: secret, PublicURL: "https://admin.example.com", Mailer: LogMailer{}}, true},
+		{"short secret", Auth{Secret: secret[:16], PublicURL: "https://admin.example.com", Mailer: LogMailer{}}, false},
+		{"no public url", Auth{Secret: secret, Mailer: LogMailer{}}, false},
+		{"relative public url", Auth{Secret: secret, PublicURL: "/admin", Mailer: LogMailer{}}, false},
+		{"no mailer", Auth{Secret: secret, PublicURL: "https://admin.example.com"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Admin{}
+			if err := WithAuth(tt.auth)(a); (err == nil) != tt.ok {
+				t.Errorf("error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
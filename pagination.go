@@ -0,0 +1,157 @@
+package crud
+
+import (
+	"net/url"
+	"strconv"
+)
+
+const (
+	// defaultPageSize is the number of rows per page when the entity does not set one.
+	defaultPageSize = 25
+	// maxPageSize is the largest page size that can be requested with the page_size query parameter.
+	maxPageSize = 500
+	// pageWindow is the number of page links shown on each side of the current page.
+	pageWindow = 2
+)
+
+// Pagination represents the page metadata of a list.
+type Pagination struct {
+	Page       int
+	PageSize   int
+	Total      int
+	TotalPages int
+
+	HasPrev bool
+	HasNext bool
+	PrevURL string
+	NextURL string
+
+	Pages []PageLink
+}
+
+// PageLink represents a link to a page of a list. a link with Gap set stands for skipped pages.
+type PageLink struct {
+	Number int
+	URL    string
+	Active bool
+	Gap    bool
+}
+
+// pageParams reads the page and page size from the query string.
+func pageParams(query url.Values, defaultSize int) (int, int) {
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	if defaultSize <= 0 {
+		defaultSize = defaultPageSize
+	}
+
+	size, err := strconv.Atoi(query.Get("page_size"))
+	if err != nil || size < 1 {
+		size = defaultSize
+	}
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	return page, size
+}
+
+// newPagination builds the page metadata. links keep every other query parameter of u. a page past the last
+// page is shown as the last page.
+func newPagination(u *url.URL, page, pageSize, total int) Pagination {
+	totalPages := (total + pageSize - 1) / pageSize
+	if totalPages < 1 {
+		totalPages = 1
+	}
+	page = min(page, totalPages)
+
+	p := Pagination{
+		Page:       page,
+		PageSize:   pageSize,
+		Total:      total,
+		TotalPages: totalPages,
+		HasPrev:    page > 1,
+		HasNext:    page < totalPages,
+	}
+
+	if p.HasPrev {
+		p.PrevURL = pageURL(u, page-1)
+	}
+	if p.HasNext {
+		p.NextURL = pageURL(u, page+1)
+	}
+
+	start, end := page-pageWindow, page+pageWindow
+	if start < 1 {
+		start = 1
+	}
+	if end > totalPages {
+		end = totalPages
+	}
+
+	if start > 1 {
+		p.Pages = append(p.Pages, PageLink{Number: 1, URL: pageURL(u, 1)})
+		if start > 2 {
+			p.Pages = append(p.Pages, PageLink{Gap: true})
+		}
+	}
+
+	for i := start; i <= end; i++ {
+		p.Pages = append(p.Pages, PageLink{Number: i, URL: pageURL(u, i), Active: i == page})
+	}
+
+	if end < totalPages {
+		if end < totalPages-1 {
+			p.Pages = append(p.Pages, PageLink{Gap: true})
+		}
+		p.Pages = append(p.Pages, PageLink{Number: totalPages, URL: pageURL(u, totalPages)})
+	}
+
+	return p
+}
+
+func pageURL(u *url.URL, page int) string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+
+	out := *u
+	out.RawQuery = query.Encode()
+	return out.RequestURI()
+}
+
+// listHeaders builds the column headers. the sort link of the sorted column flips its direction.
+func listHeaders(u *url.URL, columns []string, sortable map[string]bool, sortColumn string, sortDesc bool) []ListHeader {
+	out := make([]ListHeader, 0, len(columns))
+
+	for _, column := range columns {
+		header := ListHeader{
+			Name:     column,
+			Sortable: sortable[column],
+			Sorted:   column == sortColumn,
+			SortDesc: column == sortColumn && sortDesc,
+		}
+
+		if header.Sortable {
+			dir := "asc"
+			if header.Sorted && !sortDesc {
+				dir = "desc"
+			}
+
+			query := u.Query()
+			query.Set("sort", column)
+			query.Set("dir", dir)
+			query.Del("page")
+
+			link := *u
+			link.RawQuery = query.Encode()
+			header.SortURL = link.RequestURI()
+		}
+
+		out = append(out, header)
+	}
+
+	return out
+}
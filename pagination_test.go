@@ -0,0 +1,73 @@
+package crud
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestPageParams(t *testing.T) {
+	tests := []struct {
+		query       string
+		defaultSize int
+		page, size  int
+	}{
+		{"", 0, 1, defaultPageSize},
+		{"page=3&page_size=10", 0, 3, 10},
+		{"page=0&page_size=-1", 20, 1, 20},
+		{"page=x&page_size=y", 20, 1, 20},
+		{"page_size=100000", 0, 1, maxPageSize},
+	}
+
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.query)
+		if page, size := pageParams(query, tt.defaultSize); page != tt.page || size != tt.size {
+			t.Errorf("%q: page %d of size %d, want %d of size %d", tt.query, page, size, tt.page, tt.size)
+		}
+	}
+}
+
+func TestNewPagination(t *testing.T) {
+	u, _ := url.Parse("/admin/entity/notes?q=go&page=2")
+
+	tests := []struct {
+		page, total int
+		wantPage    int
+		wantPages   int
+	}{
+		{1, 45, 1, 3},
+		{3, 45, 3, 3},
+		{9, 45, 3, 3},
+		{1, 0, 1, 1},
+	}
+
+	for _, tt := range tests {
+		p := newPagination(u, tt.page, 20, tt.total)
+		if p.Page != tt.wantPage || p.TotalPages != tt.wantPages {
+			t.Errorf("page %d of %d rows: page = %d of %d, want %d of %d", tt.page, tt.total, p.Page, p.TotalPages, tt.wantPage, tt.wantPages)
+		}
+	}
+
+	p := newPagination(u, 2, 20, 45)
+	if p.PrevURL != "/admin/entity/notes?page=1&q=go" || p.NextURL != "/admin/entity/notes?page=3&q=go" {
+		t.Errorf("links = %s and %s, want the other parameters kept", p.PrevURL, p.NextURL)
+	}
+}
+
+func TestNewPaginationWindow(t *testing.T) {
+	u, _ := url.Parse("/admin/entity/notes")
+	p := newPagination(u, 10, 10, 200)
+
+	numbers := make([]int, 0, len(p.Pages))
+	for _, link := range p.Pages {
+		numbers = append(numbers, link.Number)
+		if link.Active != (link.Number == 10) {
+			t.Errorf("page %d active = %v", link.Number, link.Active)
+		}
+	}
+
+	// gaps have no number.
+	if want := []int{1, 0, 8, 9, 10, 11, 12, 0, 20}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("page links = %v, want %v", numbers, want)
+	}
+}
@@ -0,0 +1,55 @@
+package crud
+
+import (
+	"fmt"
+	"strings"
+)
+
+// query represents the arguments of a statement being built. identifiers are quoted with the
+// dialect and every value is bound as an argument, nothing from a request is written in the SQL text.
+type query struct {
+	dialect Dialect
+	args    []any
+}
+
+// newQuery returns an empty query for the database dialect.
+func (d *DB) newQuery() *query {
+	return &query{dialect: d.Dialect}
+}
+
+// ident returns the quoted identifier.
+func (q *query) ident(name string) string {
+	return q.dialect.QuoteIdent(name)
+}
+
+// idents returns the comma separated quoted identifiers. * is kept as is.
+func (q *query) idents(names []string) string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		if name == "*" {
+			out = append(out, name)
+			continue
+		}
+		out = append(out, q.ident(name))
+	}
+
+	return strings.Join(out, ",")
+}
+
+// arg binds a value and returns its placeholder.
+func (q *query) arg(value any) string {
+	q.args = append(q.args, value)
+	return q.dialect.Placeholder(len(q.args))
+}
+
+// checkColumns returns an error if a column is not in the table, so names coming from a request
+// never reach a statement unchecked.
+func checkColumns(table *Table, columns []Column) error {
+	for _, column := range columns {
+		if _, ok := table.Column(column.Name); !ok {
+			return fmt.Errorf("column %q does not exist in table %q", column.Name, table.Name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package crud
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	q := (&DB{Dialect: postgresDialect{}}).newQuery()
+
+	if got := q.idents([]string{"id", "*", `ti"tle`}); got != `"id",*,"ti""tle"` {
+		t.Errorf("idents = %s", got)
+	}
+
+	if got := q.arg("x") + "," + q.arg(2); got != "$1,$2" {
+		t.Errorf("placeholders = %s", got)
+	}
+	if !reflect.DeepEqual(q.args, []any{"x", 2}) {
+		t.Errorf("args = %v", q.args)
+	}
+}
+
+func TestCheckColumns(t *testing.T) {
+	table := &Table{Name: "notes", Columns: []Column{{Name: "id"}, {Name: "title"}}}
+
+	if err := checkColumns(table, []Column{{Name: "title"}}); err != nil {
+		t.Errorf("known column: %v", err)
+	}
+	if err := checkColumns(table, []Column{{Name: "title"}, {Name: "title = 'x', id"}}); err == nil {
+		t.Error("unknown column: want an error")
+	}
+}
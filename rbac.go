@@ -0,0 +1,483 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rbacAll is the entity or action of a permission granting every entity or action.
+const rbacAll = "*"
+
+// rbacAdminRole is the role created with every permission when there is no role.
+const rbacAdminRole = "admin"
+
+// rbacActions represents the actions granted by the permissions, before the custom actions.
+var rbacActions = []string{"read", "create", "update", "delete", "export"}
+
+// RBAC represents the built-in role based access control. roles, the actions they grant on the entities and the
+// roles of the users are stored in tables created by crud, and managed from the admin as entities.
+type RBAC struct {
+	// RolesTable represents the table of the roles. default is crud_roles.
+	RolesTable string
+	// PermissionsTable represents the table of the actions granted by the roles. default is crud_permissions.
+	PermissionsTable string
+	// UserRolesTable represents the table of the roles of the users. default is crud_user_roles.
+	UserRolesTable string
+	// Actions represents the custom actions granted by the permissions, in addition to read, create, update,
+	// delete and export.
+	Actions []string
+}
+
+// rbacGrant represents an action granted on an entity.
+type rbacGrant struct {
+	entity string
+	action string
+}
+
+// rbacCache represents the grants of the user of a request, loaded once per request.
+type rbacCache struct {
+	userID string
+	grants map[rbacGrant]bool
+}
+
+// rbacContextKey is the request context key of the grants cache.
+type rbacContextKey struct{}
+
+// prepareRBAC creates the tables of the access control, registers them as entities and creates the admin role
+// when there is no role. the permissions are checked with the roles when no permission checker is set.
+// it runs once the entities are discovered, so the permissions can name every entity.
+func (a *Admin) prepareRBAC(ctx context.Context) error {
+	if a.rbac == nil {
+		return nil
+	}
+
+	// the roles are checked for the user of the request, without one nothing would be checked.
+	if a.UserIdentifier == nil {
+		return errors.New("rbac needs WithAuth or a user identifier")
+	}
+
+	stmts, err := rbacTableStmts(a.db.Dialect, a.rbac)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		if _, err := a.db.querier().ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create rbac tables: %w", err)
+		}
+	}
+
+	if err := a.createAdminRole(ctx); err != nil {
+		return err
+	}
+
+	order := 0
+	for _, entity := range a.Entities {
+		if entity.Order > order {
+			order = entity.Order
+		}
+	}
+
+	a.Entities[a.rbac.RolesTable] = Entity{
+		TableName:     a.rbac.RolesTable,
+		TitlePlural:   "Roles",
+		TitleSingular: "Role",
+		Description:   "Roles grant actions on the entities to their users",
+		FavIcon:       "fa-user-shield",
+		Order:         order + 1,
+		SelectColumns: []string{"id", "name", "description"},
+		EditColumns:   []string{"name", "description"},
+		SearchColumns: []string{"name"},
+		Widgets:       map[string]Widget{"description": TextareaWidget{Rows: 3}},
+		Inlines: []Inline{
+			{Entity: a.rbac.PermissionsTable, Columns: []string{"entity", "action"}},
+			{Entity: a.rbac.UserRolesTable, Columns: []string{"user_id"}, Title: "Users"},
+		},
+	}
+
+	a.Entities[a.rbac.PermissionsTable] = Entity{
+		TableName:     a.rbac.PermissionsTable,
+		TitlePlural:   "Permissions",
+		TitleSingular: "Permission",
+		Description:   "Actions granted by the roles, * grants every entity or action",
+		FavIcon:       "fa-key",
+		Order:         order + 2,
+		SelectColumns: []string{"id", "role_id", "entity", "action"},
+		EditColumns:   []string{"role_id", "entity", "action"},
+		SearchColumns: []string{"entity", "action"},
+		Filters: []Filter{
+			{Column: "role_id", Label: "Role"},
+			{Column: "entity", Kind: FilterIn},
+			{Column: "action", Kind: FilterIn},
+		},
+	}
+
+	a.Entities[a.rbac.UserRolesTable] = Entity{
+		TableName:     a.rbac.UserRolesTable,
+		TitlePlural:   "User roles",
+		TitleSingular: "User role",
+		Description:   "Roles of the users, by the id of the user",
+		FavIcon:       "fa-users-cog",
+		Order:         order + 3,
+		SelectColumns: []string{"id", "user_id", "role_id"},
+		EditColumns:   []string{"user_id", "role_id"},
+		SearchColumns: []string{"user_id"},
+		Filters:       []Filter{{Column: "user_id", Label: "User"}, {Column: "role_id", Label: "Role"}},
+	}
+
+	// with the built-in authentication the user ids are the ids of the accounts, looked up by their email.
+	if a.auth != nil {
+		userRoles := a.Entities[a.rbac.UserRolesTable]
+		userRoles.Widgets = map[string]Widget{"user_id": LookupWidget{URL: path.Join(a.BaseURL, "/users/lookup")}}
+		a.Entities[a.rbac.UserRolesTable] = userRoles
+	}
+
+	// the permissions can name every entity, the access control ones included.
+	permissions := a.Entities[a.rbac.PermissionsTable]
+	permissions.Choices = map[string][]Choice{"entity": a.rbacEntityChoices(), "action": a.rbacActionChoices()}
+	a.Entities[a.rbac.PermissionsTable] = permissions
+
+	if a.PermissionChecker == nil {
+		a.PermissionChecker = a.rbacAllowed
+	}
+
+	return nil
+}
+
+// rbacTableStmts returns the statements creating the tables of the access control.
+func rbacTableStmts(dialect Dialect, rbac *RBAC) ([]string, error) {
+	id, err := serialPrimaryKey(dialect)
+	if err != nil {
+		return nil, fmt.Errorf("rbac: %w", err)
+	}
+
+	roles := dialect.QuoteIdent(rbac.RolesTable)
+	return []string{
+		fmt.Sprintf(`create table if not exists %s (
+	id %s,
+	name varchar(100) not null unique,
+	description varchar(255)
+)`, roles, id),
+		fmt.Sprintf(`create table if not exists %s (
+	id %s,
+	role_id bigint not null,
+	entity varchar(255) not null,
+	action varchar(100) not null,
+	unique (role_id, entity, action),
+	foreign key (role_id) references %s (id) on delete cascade
+)`, dialect.QuoteIdent(rbac.PermissionsTable), id, roles),
+		fmt.Sprintf(`create table if not exists %s (
+	id %s,
+	user_id varchar(64) not null,
+	role_id bigint not null,
+	unique (user_id, role_id),
+	foreign key (role_id) references %s (id) on delete cascade
+)`, dialect.QuoteIdent(rbac.UserRolesTable), id, roles),
+	}, nil
+}
+
+// createAdminRole creates the admin role, granting every action on every entity, when there is no role.
+func (a *Admin) createAdminRole(ctx context.Context) error {
+	q := a.db.newQuery()
+	var count int
+	if err := a.db.querier().QueryRowContext(ctx, fmt.Sprintf("select count(*) from %s", q.ident(a.rbac.RolesTable))).Scan(&count); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	return a.db.WithTx(ctx, func(db *DB) error {
+		q := db.newQuery()
+		stmt := fmt.Sprintf("insert into %s (%s) values (%s,%s)", q.ident(a.rbac.RolesTable), q.idents([]string{"name", "description"}),
+			q.arg(rbacAdminRole), q.arg("Every action on every entity"))
+		if _, err := db.querier().ExecContext(ctx, stmt, q.args...); err != nil {
+			return fmt.Errorf("create role %q: %w", rbacAdminRole, err)
+		}
+
+		roleID, err := roleID(ctx, db, a.rbac, rbacAdminRole)
+		if err != nil {
+			return err
+		}
+
+		q = db.newQuery()
+		stmt = fmt.Sprintf("insert into %s (%s) values (%s,%s,%s)", q.ident(a.rbac.PermissionsTable), q.idents([]string{"role_id", "entity", "action"}),
+			q.arg(roleID), q.arg(rbacAll), q.arg(rbacAll))
+		_, err = db.querier().ExecContext(ctx, stmt, q.args...)
+		return err
+	})
+}
+
+// rbacEntityChoices returns the entities the permissions can name, every entity first.
+func (a *Admin) rbacEntityChoices() []Choice {
+	entities := make([]Entity, 0, len(a.Entities))
+	for _, entity := range a.Entities {
+		entities = append(entities, entity)
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Order == entities[j].Order {
+			return entities[i].TableName < entities[j].TableName
+		}
+		return entities[i].Order < entities[j].Order
+	})
+
+	choices := []Choice{{Value: rbacAll, Label: "All entities"}}
+	for _, entity := range entities {
+		label := entity.TitlePlural
+		if label == "" {
+			label = entity.TableName
+		}
+		choices = append(choices, Choice{Value: entity.TableName, Label: label})
+	}
+
+	// the join tables of the many to many relations are granted by name, the links being rows of their own.
+	for _, entity := range entities {
+		for _, m := range entity.ManyToMany {
+			if _, ok := a.Entities[m.JoinTable]; ok || m.JoinTable == "" || slices.ContainsFunc(choices, func(c Choice) bool { return c.Value == m.JoinTable }) {
+				continue
+			}
+			choices = append(choices, Choice{Value: m.JoinTable, Label: m.JoinTable})
+		}
+	}
+
+	return choices
+}
+
+// rbacActionChoices returns the actions the permissions can grant, every action first.
+func (a *Admin) rbacActionChoices() []Choice {
+	choices := []Choice{{Value: rbacAll, Label: "All actions"}}
+
+	actions := slices.Clone(rbacActions)
+	for _, action := range a.rbac.Actions {
+		if action != "" && action != rbacAll && !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+
+	for _, action := range actions {
+		choices = append(choices, Choice{Value: action})
+	}
+
+	return choices
+}
+
+// roleID returns the id of a role by its name, or sql.ErrNoRows.
+func roleID(ctx context.Context, db *DB, rbac *RBAC, name string) (any, error) {
+	q := db.newQuery()
+	stmt := fmt.Sprintf("select %s from %s where %s = %s", q.ident("id"), q.ident(rbac.RolesTable), q.ident("name"), q.arg(name))
+
+	var id any
+	err := db.querier().QueryRowContext(ctx, stmt, q.args...).Scan(&id)
+	return id, err
+}
+
+// AssignRole gives a role, by its name, to a user. the user id is the one returned by the user identifier, the
+// id of the admin user with the built-in authentication.
+func (a *Admin) AssignRole(ctx context.Context, userID, role string) error {
+	if a.rbac == nil {
+		return errors.New("built-in rbac is not enabled")
+	}
+
+	return a.assignRole(ctx, a.db, userID, role)
+}
+
+// assignRole gives a role to a user with the statements of db, which may run a transaction.
+func (a *Admin) assignRole(ctx context.Context, db *DB, userID, role string) error {
+	id, err := roleID(ctx, db, a.rbac, role)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("role %q does not exist", role)
+	}
+	if err != nil {
+		return err
+	}
+
+	q := db.newQuery()
+	stmt := fmt.Sprintf("select count(*) from %s where %s = %s and %s = %s", q.ident(a.rbac.UserRolesTable),
+		q.ident("user_id"), q.arg(userID), q.ident("role_id"), q.arg(id))
+
+	var count int
+	if err := db.querier().QueryRowContext(ctx, stmt, q.args...).Scan(&count); err != nil || count > 0 {
+		return err
+	}
+
+	q = db.newQuery()
+	stmt = fmt.Sprintf("insert into %s (%s) values (%s,%s)", q.ident(a.rbac.UserRolesTable), q.idents([]string{"user_id", "role_id"}),
+		q.arg(userID), q.arg(id))
+	_, err = db.querier().ExecContext(ctx, stmt, q.args...)
+	return err
+}
+
+// assignFirstAdmin gives the admin role to the first user of the built-in authentication, so the first account
+// can manage the access of the others. it runs in the transaction creating the user, once it found no user.
+func (a *Admin) assignFirstAdmin(ctx context.Context, db *DB, userID string) error {
+	if a.rbac == nil {
+		return nil
+	}
+
+	return a.assignRole(ctx, db, userID, rbacAdminRole)
+}
+
+// accountUsers reports whether the user ids of an entity are the ids of the accounts of the built-in
+// authentication, which is the case of the user roles.
+func (a *Admin) accountUsers(entity Entity) bool {
+	return a.auth != nil && a.rbac != nil && entity.TableName == a.rbac.UserRolesTable
+}
+
+// accountEmails returns the emails of the accounts of the user ids, by id. ids of no account are left out.
+func (a *Admin) accountEmails(ctx context.Context, userIDs []string) (map[string]string, error) {
+	keys := make([]any, 0, len(userIDs))
+	for _, id := range userIDs {
+		// the account ids are numbers, other values are not compared to them.
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil && strconv.FormatInt(n, 10) == id {
+			keys = append(keys, n)
+		}
+	}
+
+	return a.db.GetDisplayValues(ctx, a.auth.TableName, "id", "email", keys)
+}
+
+// loadAccounts sets the email of the account of the user id of the user roles rows. the accounts have no page,
+// so the related rows have no url.
+func (a *Admin) loadAccounts(ctx context.Context, rows []Row) error {
+	ids := make([]string, 0, len(rows))
+	for _, row := range rows {
+		for _, column := range row.Columns {
+			if column.Name == "user_id" && !column.IsNull() {
+				ids = append(ids, column.Text())
+			}
+		}
+	}
+
+	emails, err := a.accountEmails(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("load the accounts of user_id: %w", err)
+	}
+
+	for _, row := range rows {
+		for i, column := range row.Columns {
+			if column.Name != "user_id" || column.IsNull() {
+				continue
+			}
+			if email, ok := emails[column.Text()]; ok {
+				row.Columns[i].Related = &RelatedRow{Text: email}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkAccounts returns the errors of the user ids of no account.
+func (a *Admin) checkAccounts(ctx context.Context, columns []Column) (FieldErrors, error) {
+	errs := make(FieldErrors)
+
+	for _, column := range columns {
+		if column.Name != "user_id" || column.IsNull() {
+			continue
+		}
+
+		emails, err := a.accountEmails(ctx, []string{column.Text()})
+		if err != nil {
+			return nil, fmt.Errorf("load the account of user_id: %w", err)
+		}
+		if _, ok := emails[column.Text()]; !ok {
+			errs[column.Name] = "does not exist"
+		}
+	}
+
+	return errs, nil
+}
+
+// lookupUsers answers the lookup widget of the user ids of the user roles with the accounts whose email contains
+// the q parameter, as json. it needs the read permission of the user roles, which show the emails too.
+func (a *Admin) lookupUsers(w http.ResponseWriter, r *http.Request) {
+	if !a.can(r, a.rbac.UserRolesTable, "read") {
+		a.renderNotAuthorised(w, r)
+		return
+	}
+
+	results, err := a.db.LookupEntity(r.Context(), a.auth.TableName, "id", "email", strings.TrimSpace(r.URL.Query().Get("q")), lookupLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"results": results}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// loadGrants is a middleware adding the grants cache to the request context, so the permissions of the user
+// are read once per request.
+func (a *Admin) loadGrants(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rbacContextKey{}, &rbacCache{})))
+	})
+}
+
+// rbacAllowed is the permission checker of the built-in rbac. it reports whether a role of the user grants the
+// action on the entity. the user is denied when the roles can not be read.
+func (a *Admin) rbacAllowed(r *http.Request, userID, entityName, action string) bool {
+	cache, _ := r.Context().Value(rbacContextKey{}).(*rbacCache)
+	if cache == nil || cache.grants == nil || cache.userID != userID {
+		grants, err := a.userGrants(r.Context(), userID)
+		if err != nil {
+			log.Printf("crud: read the permissions of user %q: %v", userID, err)
+			return false
+		}
+
+		if cache == nil {
+			cache = &rbacCache{}
+		}
+		cache.userID, cache.grants = userID, grants
+	}
+
+	for _, entity := range []string{entityName, rbacAll} {
+		for _, act := range []string{action, rbacAll} {
+			if cache.grants[rbacGrant{entity: entity, action: act}] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// userGrants returns the actions granted to a user by their roles.
+func (a *Admin) userGrants(ctx context.Context, userID string) (map[rbacGrant]bool, error) {
+	q := a.db.newQuery()
+	stmt := fmt.Sprintf("select p.%s, p.%s from %s p join %s ur on ur.%s = p.%s join %s r on r.%s = p.%s where ur.%s = %s",
+		q.ident("entity"), q.ident("action"), q.ident(a.rbac.PermissionsTable), q.ident(a.rbac.UserRolesTable),
+		q.ident("role_id"), q.ident("role_id"), q.ident(a.rbac.RolesTable), q.ident("id"), q.ident("role_id"),
+		q.ident("user_id"), q.arg(userID))
+
+	rows, err := a.db.querier().QueryContext(ctx, stmt, q.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := make(map[rbacGrant]bool)
+	for rows.Next() {
+		var grant rbacGrant
+		if err := rows.Scan(&grant.entity, &grant.action); err != nil {
+			return nil, err
+		}
+		grants[grant] = true
+	}
+
+	return grants, rows.Err()
+}
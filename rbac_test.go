@@ -0,0 +1,202 @@
+package crud
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRBAC(t *testing.T) {
+	db := newTestDB(t,
+		"create table notes (id integer primary key, title text not null)",
+		"insert into notes values (1, 'first')",
+	)
+	a := newTestAdmin(t, db, WithEntity(Entity{TableName: "notes"}), WithUserIdentifier(headerUser), WithRBAC(RBAC{}))
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		"insert into crud_roles (name) values ('reader')",
+		"insert into crud_permissions (role_id, entity, action) select id, 'notes', 'read' from crud_roles where name = 'reader'",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.AssignRole(ctx, "ann", rbacAdminRole); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AssignRole(ctx, "bob", "reader"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.AssignRole(ctx, "bob", "missing"); err == nil {
+		t.Error("assign a missing role: want an error")
+	}
+
+	h := a.GetMux()
+	tests := []struct {
+		name   string
+		r      *http.Request
+		denied bool
+	}{
+		{"admin reads", testRequest(http.MethodGet, "/admin/entity/notes", "ann", nil), false},
+		{"admin updates", testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "ann", url.Values{"title": {"edited"}}), false},
+		{"admin reads the roles", testRequest(http.MethodGet, "/admin/entity/crud_roles", "ann", nil), false},
+		{"reader reads", testRequest(http.MethodGet, "/admin/entity/notes", "bob", nil), false},
+		{"reader updates", testRequest(http.MethodPost, "/admin/entity/notes/1/edit", "bob", url.Values{"title": {"mine"}}), true},
+		{"reader reads the roles", testRequest(http.MethodGet, "/admin/entity/crud_roles", "bob", nil), true},
+		{"user without a role", testRequest(http.MethodGet, "/admin/entity/notes", "eve", nil), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.r)
+			if denied := strings.Contains(w.Body.String(), "Permission denied"); denied != tt.denied {
+				t.Errorf("denied = %v, want %v", denied, tt.denied)
+			}
+		})
+	}
+
+	var title string
+	if err := db.QueryRow("select title from notes where id = 1").Scan(&title); err != nil {
+		t.Fatal(err)
+	}
+	if title != "edited" {
+		t.Errorf("title = %q, want the edit of the admin", title)
+	}
+}
+
+func TestRBACFirstAdmin(t *testing.T) {
+	auth := Auth{Secret: []byte("0123456789abcdef0123456789abcdef"), PublicURL: "https://admin.example.com", Mailer: LogMailer{}}
+	ctx := context.Background()
+
+	// userRoles returns the number of roles given to the users.
+	userRoles := func(db *sql.DB) int {
+		var count int
+		if err := db.QueryRow("select count(*) from crud_user_roles").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		return count
+	}
+
+	db := newTestDB(t)
+	a := newTestAdmin(t, db, WithAuth(auth), WithRBAC(RBAC{}))
+	for _, email := range []string{"ann@example.com", "bob@example.com"} {
+		if err := a.CreateUser(ctx, "User", email, "correct horse battery"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count := userRoles(db); count != 1 {
+		t.Errorf("user roles = %d, want the admin role of the first account only", count)
+	}
+
+	// an account created before the access control is enabled is the first one.
+	db = newTestDB(t)
+	a = newTestAdmin(t, db, WithAuth(auth), WithPermissionChecker(readerChecker))
+	if err := a.CreateUser(ctx, "Ann", "ann@example.com", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+
+	a = newTestAdmin(t, db, WithAuth(auth), WithRBAC(RBAC{}))
+	if err := a.CreateUser(ctx, "Bob", "bob@example.com", "correct horse battery"); err != nil {
+		t.Fatal(err)
+	}
+	if count := userRoles(db); count != 0 {
+		t.Errorf("user roles = %d, want none as bob is not the first account", count)
+	}
+}
+
+func TestRBACCan(t *testing.T) {
+	db := newTestDB(t, "create table orders (id integer primary key, total integer not null)")
+	a := newTestAdmin(t, db, WithEntity(Entity{TableName: "orders"}), WithUserIdentifier(headerUser),
+		WithRBAC(RBAC{Actions: []string{"approve"}}))
+	ctx := context.Background()
+
+	for _, stmt := range []string{
+		"insert into crud_roles (name) values ('approver')",
+		"insert into crud_permissions (role_id, entity, action) select id, 'orders', 'approve' from crud_roles where name = 'approver'",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.AssignRole(ctx, "ann", "approver"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		user, action string
+		want         bool
+	}{
+		{"ann", "approve", true},
+		{"ann", "update", false},
+		{"bob", "approve", false},
+		{"", "approve", false},
+	}
+
+	for _, tt := range tests {
+		r := testRequest(http.MethodPost, "/orders/1/approve", tt.user, nil)
+		if got := a.Can(r, "orders", tt.action); got != tt.want {
+			t.Errorf("%q %s: can = %v, want %v", tt.user, tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestRBACAccounts(t *testing.T) {
+	db := newTestDB(t)
+	auth := Auth{Secret: []byte("0123456789abcdef0123456789abcdef"), PublicURL: "https://admin.example.com", Mailer: LogMailer{}}
+	a := newTestAdmin(t, db, WithAuth(auth), WithUserIdentifier(headerUser), WithRBAC(RBAC{}))
+	ctx := context.Background()
+
+	for _, email := range []string{"ann@example.com", "bob@example.com"} {
+		if err := a.CreateUser(ctx, "User", email, "correct horse battery"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var roleID string
+	if err := db.QueryRow("select id from crud_roles where name = 'admin'").Scan(&roleID); err != nil {
+		t.Fatal(err)
+	}
+
+	h := a.GetMux()
+	tests := []struct {
+		name    string
+		r       *http.Request
+		want    string
+		missing string
+	}{
+		{"list shows the email", testRequest(http.MethodGet, "/admin/entity/crud_user_roles", "1", nil), "ann@example.com", ""},
+		{"unknown user", testRequest(http.MethodPost, "/admin/entity/crud_user_roles/new", "1", url.Values{"user_id": {"99"}, "role_id": {roleID}}), "does not exist", ""},
+		{"user that is no id", testRequest(http.MethodPost, "/admin/entity/crud_user_roles/new", "1", url.Values{"user_id": {"bob"}, "role_id": {roleID}}), "does not exist", ""},
+		{"lookup", testRequest(http.MethodGet, "/admin/users/lookup?q=bob", "1", nil), "bob@example.com", "ann@example.com"},
+		{"lookup without a role", testRequest(http.MethodGet, "/admin/users/lookup", "2", nil), "Permission denied", "@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := serve(h, tt.r).Body.String()
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body does not contain %q", tt.want)
+			}
+			if tt.missing != "" && strings.Contains(body, tt.missing) {
+				t.Errorf("body contains %q", tt.missing)
+			}
+		})
+	}
+
+	w := serve(h, testRequest(http.MethodPost, "/admin/entity/crud_user_roles/new", "1", url.Values{"user_id": {"2"}, "role_id": {roleID}}))
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want the user role of bob created", w.Code)
+	}
+
+	var count int
+	if err := db.QueryRow("select count(*) from crud_user_roles").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("user roles = %d, want the roles of ann and bob only", count)
+	}
+}
@@ -0,0 +1,320 @@
+package crud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// lookupLimit is the number of rows returned by the lookup endpoint.
+const lookupLimit = 20
+
+// Relation represents a column referencing the rows of another entity, like organization_id referencing
+// organizations. the referenced value is the primary key of the entity.
+type Relation struct {
+	// Column represents the referencing column name.
+	Column string
+	// Entity represents the name of the referenced entity.
+	Entity string
+	// DisplayColumn represents the column of the referenced entity shown instead of the key. default is the first
+	// search column of the entity, then a name, title or email column, then its primary key.
+	DisplayColumn string
+}
+
+// RelatedRow represents the row referenced by a relation column value.
+type RelatedRow struct {
+	// URL represents the detail page of the row, empty for the rows without a page.
+	URL string
+	// Text represents the display value of the row.
+	Text string
+}
+
+// displayColumns represents the columns used as display column of an entity, in order, when it has no search column.
+var displayColumns = []string{"name", "title", "label", "email", "username"}
+
+// displayColumn returns the default display column of an entity.
+func displayColumn(entity Entity, table *Table) string {
+	if len(entity.SearchColumns) > 0 {
+		return entity.SearchColumns[0]
+	}
+
+	for _, name := range displayColumns {
+		if _, ok := table.Column(name); ok {
+			return name
+		}
+	}
+
+	return entity.PrimaryKey
+}
+
+// prepareRelations checks the relations of the entities and adds a relation for each single column foreign key
+// referencing the primary key of a registered entity. relation columns without a widget get a lookup widget.
+// it runs once every entity is prepared.
+func (a *Admin) prepareRelations(ctx context.Context) error {
+	for name, entity := range a.Entities {
+		table, err := a.db.Table(ctx, entity.TableName)
+		if err != nil {
+			return fmt.Errorf("entity %q: %w", entity.TableName, err)
+		}
+
+		relations := make([]Relation, 0, len(entity.Relations))
+		related := make(map[string]bool)
+
+		for _, relation := range entity.Relations {
+			if _, ok := table.Column(relation.Column); !ok {
+				return fmt.Errorf("entity %q: Relations column %q does not exist", entity.TableName, relation.Column)
+			}
+
+			target, ok := a.Entities[relation.Entity]
+			if !ok {
+				return fmt.Errorf("entity %q: relation %q references unknown entity %q", entity.TableName, relation.Column, relation.Entity)
+			}
+
+			targetTable, err := a.db.Table(ctx, target.TableName)
+			if err != nil {
+				return fmt.Errorf("entity %q: %w", target.TableName, err)
+			}
+
+			if relation.DisplayColumn == "" {
+				relation.DisplayColumn = displayColumn(target, targetTable)
+			}
+			if _, ok := targetTable.Column(relation.DisplayColumn); !ok {
+				return fmt.Errorf("entity %q: relation %q display column %q does not exist in %q",
+					entity.TableName, relation.Column, relation.DisplayColumn, target.TableName)
+			}
+
+			relations = append(relations, relation)
+			related[relation.Column] = true
+		}
+
+		for _, key := range table.ForeignKeys {
+			if len(key.Columns) != 1 || related[key.Columns[0]] {
+				continue
+			}
+
+			target, ok := a.Entities[key.RefTable]
+			if !ok || target.PrimaryKey != key.RefColumns[0] {
+				continue
+			}
+
+			targetTable, err := a.db.Table(ctx, target.TableName)
+			if err != nil {
+				return fmt.Errorf("entity %q: %w", target.TableName, err)
+			}
+
+			relations = append(relations, Relation{Column: key.Columns[0], Entity: key.RefTable, DisplayColumn: displayColumn(target, targetTable)})
+			related[key.Columns[0]] = true
+		}
+
+		// the widgets are copied, the map of the caller is left as is.
+		widgets := make(map[string]Widget, len(entity.Widgets)+len(relations))
+		for column, widget := range entity.Widgets {
+			widgets[column] = widget
+		}
+		for _, relation := range relations {
+			if widgets[relation.Column] == nil {
+				widgets[relation.Column] = LookupWidget{URL: a.lookupURL(relation)}
+			}
+		}
+
+		entity.Relations = relations
+		entity.Widgets = widgets
+		a.Entities[name] = entity
+	}
+
+	return nil
+}
+
+// lookupURL returns the lookup endpoint of the entity referenced by a relation.
+func (a *Admin) lookupURL(relation Relation) string {
+	return path.Join(a.BaseURL, "/entity/", relation.Entity, "lookup") + "?" + url.Values{"display": {relation.DisplayColumn}}.Encode()
+}
+
+// loadRelated sets the referenced row of the relation columns of the rows, with one query per relation.
+// values referencing a missing row, a row out of the scope of the user or a row of an entity the user may
+// not read are left as is. the user ids of the user roles get the email of their account.
+func (a *Admin) loadRelated(r *http.Request, entity Entity, rows []Row) error {
+	ctx := r.Context()
+
+	for _, relation := range entity.Relations {
+		target := a.Entities[relation.Entity]
+		if !a.can(r, target.TableName, "read") {
+			continue
+		}
+
+		keys := make([]any, 0)
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			for _, column := range row.Columns {
+				if column.Name != relation.Column || column.IsNull() || seen[column.Text()] {
+					continue
+				}
+				seen[column.Text()] = true
+				keys = append(keys, column.Value)
+			}
+		}
+
+		if len(keys) == 0 {
+			continue
+		}
+
+		scope, err := a.scope(ctx, target)
+		if err != nil {
+			return err
+		}
+
+		texts, err := a.db.GetDisplayValues(ctx, target.TableName, target.PrimaryKey, relation.DisplayColumn, keys, scope...)
+		if err != nil {
+			return fmt.Errorf("load %s of %s: %w", relation.Entity, relation.Column, err)
+		}
+
+		for _, row := range rows {
+			for i, column := range row.Columns {
+				if column.Name != relation.Column || column.IsNull() {
+					continue
+				}
+
+				if text, ok := texts[column.Text()]; ok {
+					row.Columns[i].Related = &RelatedRow{
+						URL:  a.detailURL(target, column.Text()),
+						Text: text,
+					}
+				}
+			}
+		}
+	}
+
+	if a.accountUsers(entity) {
+		return a.loadAccounts(ctx, rows)
+	}
+
+	return nil
+}
+
+// checkRelations returns the errors of the relation columns referencing a missing row, or a row out of the
+// scope of the referenced entity, and of the user ids of the user roles of no account.
+func (a *Admin) checkRelations(ctx context.Context, entity Entity, columns []Column) (FieldErrors, error) {
+	errs := make(FieldErrors)
+
+	for _, relation := range entity.Relations {
+		target := a.Entities[relation.Entity]
+
+		for _, column := range columns {
+			if column.Name != relation.Column || column.IsNull() {
+				continue
+			}
+
+			scope, err := a.scope(ctx, target)
+			if err != nil {
+				return nil, err
+			}
+
+			texts, err := a.db.GetDisplayValues(ctx, target.TableName, target.PrimaryKey, relation.DisplayColumn, []any{column.Value}, scope...)
+			if err != nil {
+				return nil, fmt.Errorf("load %s of %s: %w", relation.Entity, relation.Column, err)
+			}
+
+			if _, ok := texts[column.Text()]; !ok {
+				errs[column.Name] = "does not exist"
+			}
+		}
+	}
+
+	if a.accountUsers(entity) {
+		accountErrs, err := a.checkAccounts(ctx, columns)
+		if err != nil {
+			return nil, err
+		}
+		for name, msg := range accountErrs {
+			errs[name] = msg
+		}
+	}
+
+	return errs, nil
+}
+
+// lookupColumns returns the columns of an entity the lookups may show: its default display column, the display
+// columns of the relations referencing it and its select columns, except passwords. other columns may hold
+// secrets.
+func (a *Admin) lookupColumns(entity Entity, table *Table) map[string]bool {
+	columns := map[string]bool{displayColumn(entity, table): true}
+
+	for _, other := range a.Entities {
+		for _, relation := range other.Relations {
+			if relation.Entity == entity.TableName {
+				columns[relation.DisplayColumn] = true
+			}
+		}
+		for _, m := range other.ManyToMany {
+			if m.Entity == entity.TableName {
+				columns[m.DisplayColumn] = true
+			}
+		}
+	}
+
+	for _, column := range entity.SelectColumns {
+		if _, ok := table.Column(column); ok {
+			columns[column] = true
+		}
+	}
+
+	// passwords are never shown.
+	for name := range columns {
+		if column, ok := table.Column(name); ok {
+			if _, ok := entity.getWidget(column).(PasswordWidget); ok {
+				delete(columns, name)
+			}
+		}
+	}
+
+	return columns
+}
+
+// lookupEntity answers the lookup widgets with the rows of the entity whose display column contains
+// the q parameter, as json. the display parameter selects the display column among the lookup columns.
+func (a *Admin) lookupEntity(w http.ResponseWriter, r *http.Request) {
+	entityName := chi.URLParam(r, "entity")
+	entity, ok := a.Entities[entityName]
+	if !ok {
+		a.renderNotFoundPage(w, r)
+		return
+	}
+
+	table, err := a.db.Table(r.Context(), entity.TableName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	display := r.URL.Query().Get("display")
+	if display == "" {
+		display = displayColumn(entity, table)
+	}
+	if !a.lookupColumns(entity, table)[display] {
+		http.Error(w, fmt.Sprintf("column %q can not be looked up", display), http.StatusBadRequest)
+		return
+	}
+
+	scope, err := a.scope(r.Context(), entity)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	results, err := a.db.LookupEntity(r.Context(), entity.TableName, entity.PrimaryKey, display, strings.TrimSpace(r.URL.Query().Get("q")), lookupLimit, scope...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"results": results}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
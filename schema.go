@@ -0,0 +1,121 @@
+package crud
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrTableNotFound is returned when a table or a view does not exist.
+var ErrTableNotFound = errors.New("table not found")
+
+// Table represents the schema of a table or a view.
+type Table struct {
+	Schema     string
+	Name       string
+	IsView     bool
+	Comment    string
+	Columns    []Column
+	PrimaryKey []string
+	// ForeignKeys represents the foreign keys of the table, in constraint name order.
+	ForeignKeys []ForeignKey
+}
+
+// ForeignKey represents a foreign key constraint. the referenced table name is qualified with its
+// schema name when it is outside the current schema.
+type ForeignKey struct {
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+// Column returns the column with the given name.
+func (t *Table) Column(name string) (Column, bool) {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column, true
+		}
+	}
+
+	return Column{}, false
+}
+
+// ColumnNames returns the column names in table order.
+func (t *Table) ColumnNames() []string {
+	out := make([]string, 0, len(t.Columns))
+	for _, column := range t.Columns {
+		out = append(out, column.Name)
+	}
+
+	return out
+}
+
+// describe returns the schema of the named column holding the given scanned value, normalized to the column type.
+// columns the schema does not know, like expressions, get the given go type.
+func (t *Table) describe(name, goType, primaryKey string, value any) Column {
+	column, ok := t.Column(name)
+	if !ok {
+		column = Column{
+			Name:     name,
+			Type:     goType,
+			Nullable: true,
+		}
+	}
+
+	column.Value = normalizeValue(column.Type, value)
+	column.IsPrimary = name == primaryKey
+
+	return column
+}
+
+// Table returns the schema of a table. the table name may be qualified with its schema name.
+// schemas are read once and cached until ResetSchemaCache is called.
+func (d *DB) Table(ctx context.Context, tableName string) (*Table, error) {
+	if d.parent != nil {
+		return d.parent.Table(ctx, tableName)
+	}
+
+	d.schemaMu.RLock()
+	table, ok := d.tables[tableName]
+	d.schemaMu.RUnlock()
+	if ok {
+		return table, nil
+	}
+
+	table, err := d.Dialect.Table(ctx, d.conn, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	d.schemaMu.Lock()
+	if d.tables == nil {
+		d.tables = make(map[string]*Table)
+	}
+	d.tables[tableName] = table
+	d.schemaMu.Unlock()
+
+	return table, nil
+}
+
+// Tables returns the names of the tables and views of a schema, in name order.
+// if schema is empty the current schema is used. names outside the current schema are qualified with their schema name.
+func (d *DB) Tables(ctx context.Context, schema string) ([]string, error) {
+	return d.Dialect.Tables(ctx, d.conn, schema)
+}
+
+// ResetSchemaCache drops the cached table schemas, they are read again on next use.
+func (d *DB) ResetSchemaCache() {
+	d.schemaMu.Lock()
+	d.tables = nil
+	d.schemaMu.Unlock()
+}
+
+// splitTableName splits a name qualified with its schema name. the schema is empty if the name is not qualified.
+func splitTableName(name string) (string, string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+
+	return "", name
+}
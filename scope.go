@@ -0,0 +1,130 @@
+package crud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// scopeContextKey is the request context key of the scopes of the request.
+type scopeContextKey struct{}
+
+// requestScopes represents the scopes of the entities for a request, read once per request.
+type requestScopes struct {
+	r      *http.Request
+	scopes map[string][]FilterValue
+}
+
+// loadScopes is a middleware adding the scopes of the request to its context, so the queries made for the
+// request only reach the rows of the user. it runs after the session is loaded.
+func (a *Admin) loadScopes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scopes := &requestScopes{r: r, scopes: make(map[string][]FilterValue)}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scopeContextKey{}, scopes)))
+	})
+}
+
+// scope returns the conditions of the rows of an entity the user of the request may reach, nil when the
+// entity has no scope. a scoped entity is not reachable outside of a request.
+func (a *Admin) scope(ctx context.Context, entity Entity) ([]FilterValue, error) {
+	if entity.Scope == nil {
+		return nil, nil
+	}
+
+	scopes, _ := ctx.Value(scopeContextKey{}).(*requestScopes)
+	if scopes == nil {
+		return nil, fmt.Errorf("entity %q is scoped and can only be reached from an admin request", entity.TableName)
+	}
+
+	if scope, ok := scopes.scopes[entity.TableName]; ok {
+		return scope, nil
+	}
+
+	var userID string
+	if a.UserIdentifier != nil {
+		userID = a.UserIdentifier(scopes.r)
+	}
+
+	scope, err := entity.Scope(scopes.r, userID)
+	if err != nil {
+		return nil, fmt.Errorf("scope of %s: %w", entity.TableName, err)
+	}
+
+	table, err := a.db.Table(ctx, entity.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, filter := range scope {
+		if _, ok := table.Column(filter.Column); !ok {
+			return nil, fmt.Errorf("scope of %s: column %q does not exist", entity.TableName, filter.Column)
+		}
+
+		switch filter.Kind {
+		case "", FilterExact, FilterIsNull:
+			if len(filter.Values) != 1 {
+				return nil, fmt.Errorf("scope of %s: the %s filter on %q must have one value", entity.TableName, filter.Kind, filter.Column)
+			}
+		case FilterIn:
+		default:
+			return nil, fmt.Errorf("scope of %s: the %s filter on %q is not supported, use exact, in or isnull", entity.TableName, filter.Kind, filter.Column)
+		}
+	}
+
+	// the scope is shared by the queries of the request, appending to it must not write to its array.
+	scope = slices.Clip(scope)
+	scopes.scopes[entity.TableName] = scope
+
+	return scope, nil
+}
+
+// scopeColumns checks that the columns written to a row keep it in the scope of its entity. the columns of
+// exact and null conditions left out of a new row are added, set to the value of the condition. errors are
+// keyed by column name.
+func scopeColumns(table *Table, scope []FilterValue, columns []Column, create bool) ([]Column, FieldErrors, error) {
+	errs := make(FieldErrors)
+
+	for _, filter := range scope {
+		i := slices.IndexFunc(columns, func(column Column) bool { return column.Name == filter.Column })
+		if i < 0 {
+			if !create {
+				continue
+			}
+
+			column, _ := table.Column(filter.Column)
+			switch {
+			case filter.Kind == FilterIsNull && filter.Values[0] == "true":
+				column.Value = nil
+			case filter.Kind == FilterIsNull || filter.Kind == FilterIn:
+				return nil, nil, fmt.Errorf("scope column %q must be a column of the new form of %s", filter.Column, table.Name)
+			default:
+				value, err := parseFormValue(column, filter.Values[0])
+				if err != nil {
+					return nil, nil, fmt.Errorf("scope column %q: %w", filter.Column, err)
+				}
+				column.Value = value
+			}
+
+			columns = append(columns, column)
+			continue
+		}
+
+		column := columns[i]
+		var ok bool
+		switch filter.Kind {
+		case FilterIsNull:
+			ok = column.IsNull() == (filter.Values[0] == "true")
+		case FilterIn:
+			ok = !column.IsNull() && slices.Contains(filter.Values, valueText(column.Value))
+		default:
+			ok = !column.IsNull() && valueText(column.Value) == filter.Values[0]
+		}
+
+		if !ok {
+			errs[column.Name] = "is out of your scope"
+		}
+	}
+
+	return columns, errs, nil
+}
@@ -0,0 +1,118 @@
+package crud
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// newScopedAdmin returns the database and the handler of an admin whose projects and labels are scoped to the organization of the user.
+// the project alpha of the first organization references the label of the second one.
+func newScopedAdmin(t *testing.T) (*sql.DB, http.Handler) {
+	db := newTestDB(t,
+		"create table labels (id integer primary key, org_id integer not null, name text not null)",
+		"create table projects (id integer primary key, org_id integer not null, name text not null, label_id integer)",
+		"create table project_labels (project_id integer not null, label_id integer not null, primary key (project_id, label_id))",
+		"insert into labels values (1, 1, 'red-label'), (2, 2, 'secret-label')",
+		"insert into projects values (1, 1, 'alpha', 2), (2, 2, 'secret-project', 1)",
+		"insert into project_labels values (1, 1), (1, 2)",
+	)
+
+	scope := func(r *http.Request, userID string) ([]FilterValue, error) {
+		return []FilterValue{{Column: "org_id", Kind: FilterExact, Values: []string{userID}}}, nil
+	}
+
+	a := newTestAdmin(t, db,
+		WithEntity(Entity{
+			TableName:   "projects",
+			EditColumns: []string{"name", "label_id"},
+			Scope:       scope,
+			Relations:   []Relation{{Column: "label_id", Entity: "labels", DisplayColumn: "name"}},
+			ManyToMany:  []ManyToMany{{Entity: "labels", JoinTable: "project_labels", LocalKey: "project_id", RemoteKey: "label_id", DisplayColumn: "name"}},
+		}),
+		WithEntity(Entity{TableName: "labels", Scope: scope}),
+		WithUserIdentifier(headerUser))
+
+	return db, a.GetMux()
+}
+
+func TestScopeHidesOtherRows(t *testing.T) {
+	_, h := newScopedAdmin(t)
+
+	tests := []struct {
+		name   string
+		r      *http.Request
+		status int
+		want   string
+	}{
+		{"list", testRequest(http.MethodGet, "/admin/entity/projects", "1", nil), http.StatusOK, "alpha"},
+		{"detail", testRequest(http.MethodGet, "/admin/entity/projects/1", "1", nil), http.StatusOK, "red-label"},
+		{"edit", testRequest(http.MethodGet, "/admin/entity/projects/1/edit", "1", nil), http.StatusOK, "red-label"},
+		{"invalid form", testRequest(http.MethodPost, "/admin/entity/projects/1/edit", "1", url.Values{
+			"name": {"alpha"}, "label_id": {"2"}, "m2m-labels": {"1", "2"},
+		}), http.StatusUnprocessableEntity, "does not exist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.r)
+			body := w.Body.String()
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("body does not contain %q", tt.want)
+			}
+			for _, hidden := range []string{"secret-project", "secret-label"} {
+				if strings.Contains(body, hidden) {
+					t.Errorf("body contains the out of scope %q", hidden)
+				}
+			}
+		})
+	}
+}
+
+func TestScopeRowsNotFound(t *testing.T) {
+	_, h := newScopedAdmin(t)
+
+	for _, r := range []*http.Request{
+		testRequest(http.MethodGet, "/admin/entity/projects/2", "1", nil),
+		testRequest(http.MethodGet, "/admin/entity/projects/2/edit", "1", nil),
+		testRequest(http.MethodPost, "/admin/entity/projects/2/edit", "1", url.Values{"name": {"taken"}}),
+		testRequest(http.MethodPost, "/admin/entity/projects/2/delete", "1", url.Values{}),
+	} {
+		if body := serve(h, r).Body.String(); !strings.Contains(body, "Page Not Found") || strings.Contains(body, "secret-project") {
+			t.Errorf("%s %s is not a page not found", r.Method, r.URL)
+		}
+	}
+}
+
+func TestScopeKeepsOtherLinks(t *testing.T) {
+	db, h := newScopedAdmin(t)
+
+	w := serve(h, testRequest(http.MethodPost, "/admin/entity/projects/1/edit", "1", url.Values{"name": {"alpha"}, "m2m-labels": {""}}))
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusFound)
+	}
+
+	var links []int
+	rows, err := db.Query("select label_id from project_labels where project_id = 1 order by label_id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		links = append(links, id)
+	}
+
+	if len(links) != 1 || links[0] != 2 {
+		t.Errorf("links = %v, want the out of scope link [2] kept", links)
+	}
+}
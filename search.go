@@ -0,0 +1,109 @@
+package crud
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// searchResultLimit is the number of results shown per entity by the built-in search.
+const searchResultLimit = 10
+
+// search runs the query on the search columns of every entity the user can read.
+// the groups follow the menu order, and entities without matches are left out.
+func (a *Admin) search(r *http.Request, query string) ([]SearchGroup, int, error) {
+	entities := make([]Entity, 0)
+	for _, entity := range a.Entities {
+		if len(entity.SearchColumns) == 0 || !a.can(r, entity.TableName, "read") {
+			continue
+		}
+		entities = append(entities, entity)
+	}
+
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Order == entities[j].Order {
+			return entities[i].TableName < entities[j].TableName
+		}
+		return entities[i].Order < entities[j].Order
+	})
+
+	groups := make([]SearchGroup, 0)
+	count := 0
+
+	for _, entity := range entities {
+		scope, err := a.scope(r.Context(), entity)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		rows, total, err := a.db.SearchEntity(r.Context(), entity.TableName, entity.PrimaryKey, entity.SearchColumns, query, entity.FullTextSearch, searchResultLimit, scope...)
+		if err != nil {
+			return nil, 0, fmt.Errorf("search %s: %w", entity.TableName, err)
+		}
+
+		if total == 0 {
+			continue
+		}
+
+		group := SearchGroup{
+			EntityName: entity.TableName,
+			Title:      entity.TitlePlural,
+			URL:        path.Join(a.BaseURL, "/entity/", entity.TableName),
+			Count:      total,
+		}
+
+		for _, row := range rows {
+			group.Results = append(group.Results, a.searchResult(entity, row))
+		}
+
+		groups = append(groups, group)
+		count += total
+	}
+
+	return groups, count, nil
+}
+
+// searchResult uses the first search column as the result title and the others as its description.
+// the result links to the detail page of the row.
+func (a *Admin) searchResult(entity Entity, row Row) SearchResult {
+	texts := make([]string, 0, len(row.Columns))
+	for _, column := range row.Columns {
+		texts = append(texts, valueText(column.Value))
+	}
+
+	result := SearchResult{
+		Link: a.detailURL(entity, valueText(row.PrimaryKeyValue)),
+	}
+
+	if len(texts) > 0 {
+		result.Title = texts[0]
+		result.Description = strings.Join(texts[1:], " - ")
+	}
+
+	if result.Title == "" {
+		result.Title = fmt.Sprintf("%s %s", entity.TitleSingular, valueText(row.PrimaryKeyValue))
+	}
+
+	return result
+}
+
+// valueText returns the text of a database value. NULL is empty and binary values show their size.
+func valueText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		if !utf8.Valid(v) {
+			return fmt.Sprintf("%d bytes", len(v))
+		}
+		return string(v)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,28 @@
+package crud
+
+import "testing"
+
+func TestSearchResult(t *testing.T) {
+	a := &Admin{BaseURL: "/admin"}
+	entity := Entity{TableName: "notes", TitleSingular: "Note"}
+
+	tests := []struct {
+		row  Row
+		want SearchResult
+	}{
+		{
+			Row{PrimaryKeyValue: int64(3), Columns: []Column{{Name: "title", Value: "Go"}, {Name: "body", Value: "tips"}}},
+			SearchResult{Title: "Go", Description: "tips", Link: "/admin/entity/notes/3"},
+		},
+		{
+			Row{PrimaryKeyValue: "a/../b ?", Columns: []Column{{Name: "title", Value: nil}}},
+			SearchResult{Title: "Note a/../b ?", Link: "/admin/entity/notes/a%2F..%2Fb%20%3F"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := a.searchResult(entity, tt.row); got != tt.want {
+			t.Errorf("result = %+v, want %+v", got, tt.want)
+		}
+	}
+}
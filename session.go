@@ -0,0 +1,449 @@
+package crud
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+)
+
+// sessionTouchInterval is how often the last use of a session is recorded, so a session is not written on
+// every request.
+const sessionTouchInterval = time.Minute
+
+// ErrNoSession is returned by a session store when a cookie value has no valid session.
+var ErrNoSession = errors.New("no session")
+
+// Session represents the session of a signed in user.
+type Session struct {
+	UserID string
+	// Remember reports whether the session cookie is kept after the browser is closed.
+	Remember   bool
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+}
+
+// SessionStore represents the storage of the sessions of the built-in authentication. the admin writes the
+// session cookie, a store converts its value to a session and back.
+type SessionStore interface {
+	// New stores a new session and returns the value of its cookie.
+	New(ctx context.Context, session Session) (string, error)
+	// Get returns the session of a cookie value, or ErrNoSession.
+	Get(ctx context.Context, value string) (*Session, error)
+	// Update stores the changed session of a cookie value and returns the new value of its cookie.
+	Update(ctx context.Context, value string, session Session) (string, error)
+	// Delete removes the session of a cookie value.
+	Delete(ctx context.Context, value string) error
+	// DeleteUser removes the sessions of a user.
+	DeleteUser(ctx context.Context, userID string) error
+}
+
+// sessionPreparer is implemented by the session stores that need the admin database.
+type sessionPreparer interface {
+	prepare(ctx context.Context, db *DB, auth *Auth) error
+}
+
+// CookieSessionStore represents a session store keeping the sessions in the cookie itself, encrypted and
+// authenticated with AES-GCM. it keeps no state, so it can not revoke a single session: logging out clears the
+// cookie in the browser, but a copy of the cookie stays valid until the session times out or every session of
+// the user is revoked with RevokeSessions. use a DBSessionStore when a logout must end the session.
+type CookieSessionStore struct {
+	// Secret represents the key of the cookies, at least 32 bytes long. default is the auth secret.
+	Secret []byte
+}
+
+// cookieSession represents a session encoded in a cookie.
+type cookieSession struct {
+	UserID     string `json:"u"`
+	Remember   bool   `json:"r,omitempty"`
+	CreatedAt  int64  `json:"c"`
+	LastSeenAt int64  `json:"l"`
+}
+
+// aead returns the cipher of the cookies.
+func (s CookieSessionStore) aead() (cipher.AEAD, error) {
+	if len(s.Secret) < 32 {
+		return nil, errors.New("cookie session secret must be at least 32 bytes")
+	}
+
+	key := sha256.Sum256(s.Secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// New returns the encrypted session.
+func (s CookieSessionStore) New(ctx context.Context, session Session) (string, error) {
+	aead, err := s.aead()
+	if err != nil {
+		return "", err
+	}
+
+	plain, err := json.Marshal(cookieSession{
+		UserID:     session.UserID,
+		Remember:   session.Remember,
+		CreatedAt:  session.CreatedAt.UnixMilli(),
+		LastSeenAt: session.LastSeenAt.UnixMilli(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, nil)), nil
+}
+
+// Get decrypts the session of a cookie value.
+func (s CookieSessionStore) Get(ctx context.Context, value string) (*Session, error) {
+	aead, err := s.aead()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, ErrNoSession
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrNoSession
+	}
+
+	var c cookieSession
+	if err := json.Unmarshal(plain, &c); err != nil {
+		return nil, ErrNoSession
+	}
+
+	return &Session{
+		UserID:     c.UserID,
+		Remember:   c.Remember,
+		CreatedAt:  time.UnixMilli(c.CreatedAt),
+		LastSeenAt: time.UnixMilli(c.LastSeenAt),
+	}, nil
+}
+
+// Update returns the encrypted changed session.
+func (s CookieSessionStore) Update(ctx context.Context, value string, session Session) (string, error) {
+	return s.New(ctx, session)
+}
+
+// Delete does nothing: the cookie store can not revoke a session, the admin only clears the cookie of the
+// browser. see CookieSessionStore.
+func (s CookieSessionStore) Delete(ctx context.Context, value string) error {
+	return nil
+}
+
+// DeleteUser does nothing, the sessions of a user are revoked by the admin through the admin users table.
+func (s CookieSessionStore) DeleteUser(ctx context.Context, userID string) error {
+	return nil
+}
+
+// DBSessionStore represents a session store keeping the sessions in a table of the admin database, created by
+// crud. the cookie holds a random token and only its hash is stored. expired sessions are removed on login.
+type DBSessionStore struct {
+	// TableName represents the table of the sessions. default is crud_sessions.
+	TableName string
+
+	db   *DB
+	auth *Auth
+}
+
+// prepare creates the sessions table.
+func (s *DBSessionStore) prepare(ctx context.Context, db *DB, auth *Auth) error {
+	if s.TableName == "" {
+		s.TableName = "crud_sessions"
+	}
+	s.db, s.auth = db, auth
+
+	stmt := fmt.Sprintf(`create table if not exists %s (
+	id varchar(64) primary key,
+	user_id varchar(64) not null,
+	remember smallint not null default 0,
+	created_at bigint not null,
+	last_seen_at bigint not null
+)`, db.Dialect.QuoteIdent(s.TableName))
+
+	if _, err := db.querier().ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("create table %q: %w", s.TableName, err)
+	}
+
+	return nil
+}
+
+// exec runs a statement on the sessions table.
+func (s *DBSessionStore) exec(ctx context.Context, build func(q *query) string) error {
+	if s.db == nil {
+		return errors.New("db session store is not prepared, set it in Auth.Sessions")
+	}
+
+	q := s.db.newQuery()
+	_, err := s.db.querier().ExecContext(ctx, build(q), q.args...)
+	return err
+}
+
+// New removes the expired sessions and stores a new one.
+func (s *DBSessionStore) New(ctx context.Context, session Session) (string, error) {
+	now := time.Now()
+	err := s.exec(ctx, func(q *query) string {
+		return fmt.Sprintf("delete from %s where %s < %s or %s < %s", q.ident(s.TableName),
+			q.ident("created_at"), q.arg(now.Add(-s.auth.AbsoluteTimeout).UnixMilli()),
+			q.ident("last_seen_at"), q.arg(now.Add(-s.auth.IdleTimeout).UnixMilli()))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	remember := 0
+	if session.Remember {
+		remember = 1
+	}
+
+	err = s.exec(ctx, func(q *query) string {
+		return fmt.Sprintf("insert into %s (%s) values (%s,%s,%s,%s,%s)", q.ident(s.TableName),
+			q.idents([]string{"id", "user_id", "remember", "created_at", "last_seen_at"}),
+			q.arg(hashToken(token)), q.arg(session.UserID), q.arg(remember), q.arg(session.CreatedAt.UnixMilli()), q.arg(session.LastSeenAt.UnixMilli()))
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Get returns the stored session of a token.
+func (s *DBSessionStore) Get(ctx context.Context, value string) (*Session, error) {
+	if s.db == nil {
+		return nil, errors.New("db session store is not prepared, set it in Auth.Sessions")
+	}
+
+	q := s.db.newQuery()
+	stmt := fmt.Sprintf("select %s from %s where %s = %s", q.idents([]string{"user_id", "remember", "created_at", "last_seen_at"}),
+		q.ident(s.TableName), q.ident("id"), q.arg(hashToken(value)))
+
+	var (
+		userID                any
+		remember              int
+		createdAt, lastSeenAt int64
+	)
+	err := s.db.querier().QueryRowContext(ctx, stmt, q.args...).Scan(&userID, &remember, &createdAt, &lastSeenAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		UserID:     valueText(userID),
+		Remember:   remember != 0,
+		CreatedAt:  time.UnixMilli(createdAt),
+		LastSeenAt: time.UnixMilli(lastSeenAt),
+	}, nil
+}
+
+// Update records the last use of the session, the token is kept.
+func (s *DBSessionStore) Update(ctx context.Context, value string, session Session) (string, error) {
+	err := s.exec(ctx, func(q *query) string {
+		return fmt.Sprintf("update %s set %s = %s where %s = %s", q.ident(s.TableName),
+			q.ident("last_seen_at"), q.arg(session.LastSeenAt.UnixMilli()), q.ident("id"), q.arg(hashToken(value)))
+	})
+
+	return value, err
+}
+
+// Delete removes the session of a token.
+func (s *DBSessionStore) Delete(ctx context.Context, value string) error {
+	return s.exec(ctx, func(q *query) string {
+		return fmt.Sprintf("delete from %s where %s = %s", q.ident(s.TableName), q.ident("id"), q.arg(hashToken(value)))
+	})
+}
+
+// DeleteUser removes the sessions of a user.
+func (s *DBSessionStore) DeleteUser(ctx context.Context, userID string) error {
+	return s.exec(ctx, func(q *query) string {
+		return fmt.Sprintf("delete from %s where %s = %s", q.ident(s.TableName), q.ident("user_id"), q.arg(userID))
+	})
+}
+
+// sessionContextKey is the request context key of the signed in user.
+type sessionContextKey struct{}
+
+// sessionCookieOf returns a session cookie of the admin with the value.
+func (a *Admin) sessionCookieOf(value string) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     path.Join(a.BaseURL, "/"),
+		HttpOnly: true,
+		Secure:   !a.insecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// setSessionCookie writes the session cookie. it is kept after the browser is closed only when the session
+// is remembered, until the session reaches its absolute timeout.
+func (a *Admin) setSessionCookie(w http.ResponseWriter, value string, session Session) {
+	cookie := a.sessionCookieOf(value)
+	if session.Remember {
+		cookie.Expires = session.CreatedAt.Add(a.auth.AbsoluteTimeout)
+	}
+
+	http.SetCookie(w, cookie)
+}
+
+// clearSessionCookie removes the session cookie.
+func (a *Admin) clearSessionCookie(w http.ResponseWriter) {
+	cookie := a.sessionCookieOf("")
+	cookie.MaxAge = -1
+	http.SetCookie(w, cookie)
+}
+
+// startSession signs the user in with a new session. the session of the request, if any, is removed, so the
+// session is rotated on every login.
+func (a *Admin) startSession(w http.ResponseWriter, r *http.Request, userID string, remember bool) error {
+	if cookie, err := r.Cookie(sessionCookie); err == nil {
+		if err := a.auth.Sessions.Delete(r.Context(), cookie.Value); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	session := Session{UserID: userID, Remember: remember, CreatedAt: now, LastSeenAt: now}
+
+	value, err := a.auth.Sessions.New(r.Context(), session)
+	if err != nil {
+		return err
+	}
+
+	a.setSessionCookie(w, value, session)
+	return nil
+}
+
+// endSession signs the user out and removes the session.
+func (a *Admin) endSession(w http.ResponseWriter, r *http.Request) error {
+	a.clearSessionCookie(w)
+
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	return a.auth.Sessions.Delete(r.Context(), cookie.Value)
+}
+
+// RevokeSessions signs a user out of all their sessions, like after a password change.
+func (a *Admin) RevokeSessions(ctx context.Context, userID string) error {
+	if a.auth == nil {
+		return errors.New("built-in auth is not enabled")
+	}
+
+	if err := a.updateUser(ctx, userID, map[string]any{"sessions_revoked_at": time.Now().UnixMilli()}); err != nil {
+		return err
+	}
+
+	return a.auth.Sessions.DeleteUser(ctx, userID)
+}
+
+// loadSession is a middleware adding the signed in user of the session cookie to the request context.
+// sessions past their idle or absolute timeout, revoked or of a deleted user are removed. the last use of
+// the session is recorded.
+func (a *Admin) loadSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := a.sessionUser(w, r, cookie.Value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if user != nil {
+			r = r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, user))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionUser returns the user of a valid session cookie value, or nil.
+func (a *Admin) sessionUser(w http.ResponseWriter, r *http.Request, value string) (*authUser, error) {
+	ctx := r.Context()
+
+	session, err := a.auth.Sessions.Get(ctx, value)
+	if err == ErrNoSession {
+		a.clearSessionCookie(w)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	valid := now.Sub(session.CreatedAt) < a.auth.AbsoluteTimeout && now.Sub(session.LastSeenAt) < a.auth.IdleTimeout
+
+	var user *authUser
+	if valid {
+		user, err = a.findUser(ctx, a.db, "id", session.UserID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		valid = err == nil && session.CreatedAt.UnixMilli() > user.sessionsRevokedAt
+	}
+
+	if !valid {
+		a.clearSessionCookie(w)
+		return nil, a.auth.Sessions.Delete(ctx, value)
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		session.LastSeenAt = now
+		value, err := a.auth.Sessions.Update(ctx, value, *session)
+		if err != nil {
+			return nil, err
+		}
+		a.setSessionCookie(w, value, *session)
+	}
+
+	return user, nil
+}
+
+// currentUser returns the signed in user of the built-in authentication, or nil.
+func currentUser(r *http.Request) *authUser {
+	user, _ := r.Context().Value(sessionContextKey{}).(*authUser)
+	return user
+}
+
+// sessionUserID returns the id of the signed in user of the built-in authentication, or an empty string.
+func (a *Admin) sessionUserID(r *http.Request) string {
+	if user := currentUser(r); user != nil {
+		return user.id
+	}
+
+	return ""
+}
@@ -0,0 +1,28 @@
+package crud
+
+import "testing"
+
+func TestSessionCookieSecure(t *testing.T) {
+	tests := []struct {
+		opts   []Option
+		secure bool
+	}{
+		{nil, true},
+		{[]Option{WithSecureCookies(true)}, true},
+		{[]Option{WithSecureCookies(false)}, false},
+	}
+
+	for _, tt := range tests {
+		a := &Admin{BaseURL: "/admin"}
+		for _, opt := range tt.opts {
+			if err := opt(a); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		cookie := a.sessionCookieOf("value")
+		if cookie.Secure != tt.secure || !cookie.HttpOnly || cookie.Path != "/admin" {
+			t.Errorf("cookie = %+v, want secure %v, http only and the admin path", cookie, tt.secure)
+		}
+	}
+}
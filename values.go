@@ -0,0 +1,184 @@
+package crud
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// FieldErrors represents the errors of the invalid fields of a form, by column name.
+type FieldErrors map[string]string
+
+// Error returns the invalid fields and their errors.
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for name, err := range e {
+		fields = append(fields, fmt.Sprintf("%s %s", name, err))
+	}
+	sort.Strings(fields)
+
+	return "invalid fields: " + strings.Join(fields, ", ")
+}
+
+// timeLayouts represents the accepted layouts of time values, the datetime-local input layouts first.
+var timeLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// errEmptyValue is returned by parseFormValue for an empty value of a column that is not text.
+var errEmptyValue = errors.New("is required")
+
+// parseFormValue converts a form value to the go value of the column type. an empty value of a column
+// that is not text is NULL if the column is nullable, and errEmptyValue otherwise. widgets use it once
+// they checked the value.
+func parseFormValue(column Column, value string) (any, error) {
+	if column.Type == "string" {
+		return value, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if column.Nullable {
+			return nil, nil
+		}
+		return nil, errEmptyValue
+	}
+
+	switch column.Type {
+	case "int":
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, errors.New("must be a whole number")
+		}
+		return v, nil
+	case "float64":
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.New("must be a number")
+		}
+		return v, nil
+	case "bool":
+		if value == "on" {
+			return true, nil
+		}
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return v, nil
+	case "time.Time":
+		for _, layout := range timeLayouts {
+			if v, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+				return v, nil
+			}
+		}
+		return nil, errors.New("must be a date and time")
+	default:
+		return value, nil
+	}
+}
+
+// nullField is the form field listing the columns set to NULL.
+const nullField = "_null"
+
+// parseForm converts the posted values of the allowed columns with their widgets, other form keys are ignored.
+// nullable columns listed in the _null field are set to NULL whatever their value. empty values of required
+// columns are errors, except for text columns which store the empty string, while columns filled by the database
+// keep their value. a widget skipping the value of a required column is an error when creating a row. values of
+// choice columns must be one of the choices, whatever their widget.
+func (e Entity) parseForm(form url.Values, allowed []Column, creating bool) ([]Column, FieldErrors) {
+	out := make([]Column, 0, len(allowed))
+	errs := make(FieldErrors)
+
+	for _, column := range allowed {
+		if column.Nullable && slices.Contains(form[nullField], column.Name) {
+			column.Value = nil
+			out = append(out, column)
+			continue
+		}
+
+		values, ok := form[column.Name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+
+		value, err := e.getWidget(column).Parse(Field{Column: column, ID: "input-" + column.Name}, values)
+		if err == ErrSkipValue {
+			if creating && column.Required() {
+				errs[column.Name] = errEmptyValue.Error()
+			}
+			continue
+		}
+
+		if column.Required() && column.Type != "string" && strings.TrimSpace(lastValue(values)) == "" {
+			errs[column.Name] = errEmptyValue.Error()
+			continue
+		}
+
+		if err == errEmptyValue && column.HasDefault {
+			continue
+		}
+		if err != nil {
+			errs[column.Name] = err.Error()
+			continue
+		}
+
+		if choices := e.getChoices(column); len(choices) > 0 && value != nil && !isChoice(choices, valueText(value)) {
+			errs[column.Name] = errInvalidChoice.Error()
+			continue
+		}
+
+		column.Value = value
+		out = append(out, column)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return out, nil
+}
+
+// normalizeValue converts a scanned value to the go value of the column type. drivers returning
+// numbers as text or booleans as numbers are read as the column type, and text is decoded from bytes.
+// NULL stays nil, so it is never confused with an empty string.
+func normalizeValue(goType string, value any) any {
+	switch v := value.(type) {
+	case []byte:
+		switch goType {
+		case "int":
+			if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+				return n
+			}
+		case "float64":
+			if n, err := strconv.ParseFloat(string(v), 64); err == nil {
+				return n
+			}
+		case "bool":
+			if b, err := strconv.ParseBool(string(v)); err == nil {
+				return b
+			}
+		}
+
+		if utf8.Valid(v) {
+			return string(v)
+		}
+	case int64:
+		if goType == "bool" {
+			return v != 0
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,66 @@
+package crud
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseForm(t *testing.T) {
+	columns := []Column{
+		{Name: "title", Type: "string"},
+		{Name: "body", Type: "string", Nullable: true},
+		{Name: "stars", Type: "int"},
+		{Name: "views", Type: "int", Nullable: true},
+		{Name: "status", Type: "string", EnumValues: []string{"draft", "published"}},
+	}
+
+	tests := []struct {
+		name   string
+		form   url.Values
+		values map[string]any
+		errs   FieldErrors
+	}{
+		{
+			"values",
+			url.Values{"title": {"Go"}, "body": {"text"}, "stars": {" 5 "}, "views": {"7"}, "status": {"draft"}},
+			map[string]any{"title": "Go", "body": "text", "stars": int64(5), "views": int64(7), "status": "draft"},
+			nil,
+		},
+		{
+			"empty text of a not null column",
+			url.Values{"title": {""}, "stars": {"1"}, "views": {""}, "status": {"draft"}},
+			map[string]any{"title": "", "stars": int64(1), "views": nil, "status": "draft"},
+			nil,
+		},
+		{
+			"null",
+			url.Values{"body": {"text"}, nullField: {"body", "title"}},
+			map[string]any{"body": nil},
+			nil,
+		},
+		{
+			"errors",
+			url.Values{"title": {"Go"}, "stars": {" "}, "views": {"many"}, "status": {"gone"}},
+			nil,
+			FieldErrors{"stars": errEmptyValue.Error(), "views": "must be a whole number", "status": errInvalidChoice.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errs := Entity{}.parseForm(tt.form, columns, false)
+			if !reflect.DeepEqual(errs, tt.errs) {
+				t.Fatalf("errors = %v, want %v", errs, tt.errs)
+			}
+
+			values := make(map[string]any)
+			for _, column := range out {
+				values[column.Name] = column.Value
+			}
+			if tt.values != nil && !reflect.DeepEqual(values, tt.values) {
+				t.Errorf("values = %#v, want %#v", values, tt.values)
+			}
+		})
+	}
+}
@@ -0,0 +1,463 @@
+package crud
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSkipValue is returned by Widget.Parse to leave the column out of the insert or update,
+// like an empty password field that keeps the stored password.
+var ErrSkipValue = errors.New("skip value")
+
+// errInvalidChoice is returned for a value that is not one of the column choices.
+var errInvalidChoice = errors.New("is not a valid choice")
+
+// Widget represents the form input of a column.
+type Widget interface {
+	// Render returns the html of the input. the input must be named after the column, and should
+	// have the field id so its label points to it.
+	Render(field Field) template.HTML
+	// Parse converts the posted values of the column, in form order, to the column value.
+	// it is only called when the field was posted.
+	Parse(field Field, values []string) (any, error)
+}
+
+// Field represents a column rendered or parsed by a widget.
+type Field struct {
+	Column
+	// ID represents the id of the input.
+	ID string
+	// Invalid reports whether the posted value of the field was rejected.
+	Invalid bool
+}
+
+// Choice represents a value a column can take in a select or radio widget.
+type Choice struct {
+	Value string
+	Label string
+}
+
+// widgetTemplates represents the templates of the built-in widgets.
+var widgetTemplates = template.Must(template.New("widgets").ParseFS(templates, "templates/widgets.html"))
+
+// widgetData represents the data of a widget template.
+type widgetData struct {
+	Field
+	InputType string
+	Step      string
+	Rows      int
+	Checked   bool
+	Choices   []widgetChoice
+	URL       string
+	Multiple  bool
+}
+
+// widgetChoice represents a choice of a select or radio widget template.
+type widgetChoice struct {
+	Choice
+	Selected bool
+}
+
+// renderWidget executes a built-in widget template.
+func renderWidget(name string, data widgetData) template.HTML {
+	var buf bytes.Buffer
+	if err := widgetTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+
+	return template.HTML(buf.String())
+}
+
+// lastValue returns the last posted value of a field.
+func lastValue(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[len(values)-1]
+}
+
+// TextWidget renders a text input. it is the default widget of text columns.
+type TextWidget struct{}
+
+// Render returns a text input.
+func (TextWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "text"})
+}
+
+// Parse returns the posted text.
+func (TextWidget) Parse(field Field, values []string) (any, error) {
+	return parseFormValue(field.Column, lastValue(values))
+}
+
+// TextareaWidget renders a multi-line text input.
+type TextareaWidget struct {
+	// Rows represents the visible number of lines. default is 5.
+	Rows int
+}
+
+// Render returns a textarea.
+func (w TextareaWidget) Render(field Field) template.HTML {
+	rows := w.Rows
+	if rows <= 0 {
+		rows = 5
+	}
+
+	return renderWidget("widget-textarea", widgetData{Field: field, Rows: rows})
+}
+
+// Parse returns the posted text.
+func (TextareaWidget) Parse(field Field, values []string) (any, error) {
+	return parseFormValue(field.Column, lastValue(values))
+}
+
+// SelectWidget renders a drop down of choices. columns that are not required get an empty choice.
+type SelectWidget struct {
+	Choices []Choice
+}
+
+// Render returns a select.
+func (w SelectWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-select", widgetData{Field: field, Choices: selectedChoices(w.Choices, field.FormValue())})
+}
+
+// Parse checks the posted value is one of the choices and converts it to the column type.
+func (w SelectWidget) Parse(field Field, values []string) (any, error) {
+	return parseChoice(field, w.Choices, lastValue(values))
+}
+
+// RadioWidget renders a radio button for each choice.
+type RadioWidget struct {
+	Choices []Choice
+}
+
+// Render returns the radio buttons.
+func (w RadioWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-radio", widgetData{Field: field, Choices: selectedChoices(w.Choices, field.FormValue())})
+}
+
+// Parse checks the posted value is one of the choices and converts it to the column type.
+func (w RadioWidget) Parse(field Field, values []string) (any, error) {
+	return parseChoice(field, w.Choices, lastValue(values))
+}
+
+// selectedChoices marks the choice holding the value.
+func selectedChoices(choices []Choice, value string) []widgetChoice {
+	out := make([]widgetChoice, 0, len(choices))
+	for _, choice := range choices {
+		if choice.Label == "" {
+			choice.Label = choice.Value
+		}
+		out = append(out, widgetChoice{Choice: choice, Selected: choice.Value == value})
+	}
+
+	return out
+}
+
+// parseChoice checks the value is one of the choices. an empty value is NULL for a nullable column,
+// and is left to the column type otherwise.
+func parseChoice(field Field, choices []Choice, value string) (any, error) {
+	if value == "" && field.Nullable {
+		return nil, nil
+	}
+	if value != "" && !isChoice(choices, value) {
+		return nil, errInvalidChoice
+	}
+
+	return parseFormValue(field.Column, value)
+}
+
+// CheckboxWidget renders a checkbox. it is the default widget of boolean columns.
+// a hidden false value is posted before the checkbox, so an unchecked box is read as false.
+type CheckboxWidget struct{}
+
+// Render returns a checkbox.
+func (CheckboxWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-checkbox", widgetData{Field: field, Checked: field.FormValue() == "true"})
+}
+
+// Parse returns whether the box is checked.
+func (CheckboxWidget) Parse(field Field, values []string) (any, error) {
+	field.Type = "bool"
+	return parseFormValue(field.Column, lastValue(values))
+}
+
+// DateWidget renders a date input. it is the default widget of date columns.
+type DateWidget struct{}
+
+// Render returns a date input.
+func (DateWidget) Render(field Field) template.HTML {
+	if t, ok := field.Value.(time.Time); ok {
+		field.Value = t.Format("2006-01-02")
+	}
+
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "date"})
+}
+
+// Parse returns the posted date.
+func (DateWidget) Parse(field Field, values []string) (any, error) {
+	value := strings.TrimSpace(lastValue(values))
+	if value == "" {
+		return parseFormValue(field.Column, value)
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.UTC)
+	if err != nil {
+		return nil, errors.New("must be a date")
+	}
+
+	return t, nil
+}
+
+// DateTimeWidget renders a datetime-local input. it is the default widget of time columns.
+type DateTimeWidget struct{}
+
+// Render returns a datetime-local input.
+func (DateTimeWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "datetime-local"})
+}
+
+// Parse returns the posted time.
+func (DateTimeWidget) Parse(field Field, values []string) (any, error) {
+	field.Type = "time.Time"
+	return parseFormValue(field.Column, lastValue(values))
+}
+
+// NumberWidget renders a number input. it is the default widget of number columns.
+type NumberWidget struct {
+	// Step represents the granularity of the value, like "0.01". default is 1 for whole number
+	// columns and "any" otherwise.
+	Step string
+}
+
+// Render returns a number input.
+func (w NumberWidget) Render(field Field) template.HTML {
+	step := w.Step
+	if step == "" {
+		step = "any"
+		if field.Type == "int" {
+			step = "1"
+		}
+	}
+
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "number", Step: step})
+}
+
+// Parse returns the posted number, as the column type.
+func (NumberWidget) Parse(field Field, values []string) (any, error) {
+	value := strings.TrimSpace(lastValue(values))
+	if field.Type == "int" || field.Type == "float64" {
+		return parseFormValue(field.Column, value)
+	}
+
+	if value == "" {
+		return parseFormValue(field.Column, value)
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err != nil {
+		return nil, errors.New("must be a number")
+	}
+
+	return value, nil
+}
+
+// PasswordWidget renders a password input. the stored value is never shown, and an empty field
+// keeps it unchanged.
+type PasswordWidget struct{}
+
+// Render returns a password input.
+func (PasswordWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-password", widgetData{Field: field})
+}
+
+// Parse returns the posted password, or ErrSkipValue if it is empty.
+func (PasswordWidget) Parse(field Field, values []string) (any, error) {
+	value := lastValue(values)
+	if value == "" {
+		return nil, ErrSkipValue
+	}
+
+	return value, nil
+}
+
+// ColorWidget renders a color picker. the value is a #rrggbb color.
+type ColorWidget struct{}
+
+// Render returns a color input.
+func (ColorWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "color"})
+}
+
+// Parse checks the posted value is a #rrggbb color.
+func (ColorWidget) Parse(field Field, values []string) (any, error) {
+	value := strings.TrimSpace(lastValue(values))
+	if value == "" {
+		return parseFormValue(field.Column, value)
+	}
+
+	if _, err := strconv.ParseUint(strings.TrimPrefix(value, "#"), 16, 32); err != nil || len(value) != 7 || value[0] != '#' {
+		return nil, errors.New("must be a color like #ff0000")
+	}
+
+	return strings.ToLower(value), nil
+}
+
+// EmailWidget renders an email input.
+type EmailWidget struct{}
+
+// Render returns an email input.
+func (EmailWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "email"})
+}
+
+// Parse checks the posted value is an email address.
+func (EmailWidget) Parse(field Field, values []string) (any, error) {
+	value := strings.TrimSpace(lastValue(values))
+	if value == "" {
+		return parseFormValue(field.Column, value)
+	}
+
+	if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+		return nil, errors.New("must be an email address")
+	}
+
+	return value, nil
+}
+
+// URLWidget renders a url input.
+type URLWidget struct{}
+
+// Render returns a url input.
+func (URLWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-input", widgetData{Field: field, InputType: "url"})
+}
+
+// Parse checks the posted value is an absolute url.
+func (URLWidget) Parse(field Field, values []string) (any, error) {
+	value := strings.TrimSpace(lastValue(values))
+	if value == "" {
+		return parseFormValue(field.Column, value)
+	}
+
+	if u, err := url.ParseRequestURI(value); err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, errors.New("must be a url")
+	}
+
+	return value, nil
+}
+
+// LookupWidget renders a select of the rows of another entity, searched with its lookup endpoint.
+// it is the default widget of relation columns.
+type LookupWidget struct {
+	// URL represents the lookup endpoint. it answers the q parameter with the matching rows as json.
+	URL string
+}
+
+// Render returns a select holding the referenced row, filled by the lookup endpoint when searched.
+func (w LookupWidget) Render(field Field) template.HTML {
+	data := widgetData{Field: field, URL: w.URL}
+	if value := field.FormValue(); value != "" {
+		choice := Choice{Value: value, Label: value}
+		if field.Related != nil {
+			choice.Label = field.Related.Text
+		}
+		data.Choices = []widgetChoice{{Choice: choice, Selected: true}}
+	}
+
+	return renderWidget("widget-select", data)
+}
+
+// Parse converts the posted key to the column type. an empty value is NULL for a nullable column.
+func (LookupWidget) Parse(field Field, values []string) (any, error) {
+	value := strings.TrimSpace(lastValue(values))
+	if value == "" && field.Nullable {
+		return nil, nil
+	}
+
+	return parseFormValue(field.Column, value)
+}
+
+// HiddenWidget renders a hidden input, without a label.
+type HiddenWidget struct{}
+
+// Render returns a hidden input.
+func (HiddenWidget) Render(field Field) template.HTML {
+	return renderWidget("widget-hidden", widgetData{Field: field})
+}
+
+// Parse converts the posted value to the column type.
+func (HiddenWidget) Parse(field Field, values []string) (any, error) {
+	return parseFormValue(field.Column, lastValue(values))
+}
+
+// defaultWidget returns the widget of a column type.
+func defaultWidget(column Column) Widget {
+	switch column.Type {
+	case "int", "float64":
+		return NumberWidget{}
+	case "bool":
+		return CheckboxWidget{}
+	case "time.Time":
+		if column.DatabaseType == "date" {
+			return DateWidget{}
+		}
+		return DateTimeWidget{}
+	default:
+		return TextWidget{}
+	}
+}
+
+// getWidget returns the entity widget of a column, a select of its choices, or the widget of its type.
+func (e Entity) getWidget(column Column) Widget {
+	if widget, ok := e.Widgets[column.Name]; ok && widget != nil {
+		return widget
+	}
+
+	if choices := e.getChoices(column); len(choices) > 0 {
+		return SelectWidget{Choices: choices}
+	}
+
+	return defaultWidget(column)
+}
+
+// getChoices returns the entity choices of a column, or the allowed values read from the database.
+func (e Entity) getChoices(column Column) []Choice {
+	if choices, ok := e.Choices[column.Name]; ok {
+		return choices
+	}
+
+	choices := make([]Choice, 0, len(column.EnumValues))
+	for _, value := range column.EnumValues {
+		choices = append(choices, Choice{Value: value})
+	}
+
+	return choices
+}
+
+// choiceLabel returns the label of the choice of a value, or the value itself.
+func choiceLabel(choices []Choice, value string) string {
+	for _, choice := range choices {
+		if choice.Value == value && choice.Label != "" {
+			return choice.Label
+		}
+	}
+
+	return value
+}
+
+// isChoice reports whether a value is one of the choices.
+func isChoice(choices []Choice, value string) bool {
+	for _, choice := range choices {
+		if choice.Value == value {
+			return true
+		}
+	}
+
+	return false
+}